@@ -0,0 +1,253 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Aggregator accumulates the progress of a component, such as imported
+// blocks and their gas, and logs it as one summary event per Interval or per
+// Updates updates, whichever comes first, in the style of the "Imported new
+// chain segment" lines:
+//
+//	agg := log.NewAggregator(l, "Imported new chain segment", 8*time.Second)
+//	defer agg.Flush()
+//	for _, b := range blocks {
+//		agg.Add("blocks", 1)
+//		agg.Observe("gas", float64(b.GasUsed()))
+//	}
+//
+// The summary holds, for each key passed to Add, the sum under the key and
+// the rate per second under key_rate, and for each key passed to Observe, the
+// key_count, key_sum, key_min, key_max and key_rate fields, followed by the
+// ElapsedFieldName field. A window starts with the first update after the
+// previous summary, and the rates are computed over the time elapsed since
+// then, so idle periods do not lower them. The keys are logged in the order
+// they were first seen, and those not updated during the window log zero
+// sums only. A key passed to
+// both Add and Observe keeps the first kind of update it got: the others are
+// dropped and reported through ErrorHandler.
+//
+// Summaries are logged by the Add and Observe calls that make them due, by a
+// timer once Interval has elapsed since the first update of a window, and by
+// Flush. An Aggregator is safe for concurrent use.
+type Aggregator struct {
+	// Logger logs the summaries.
+	Logger Logger
+
+	// Message is the message of the summaries.
+	Message string
+
+	// Level is the level of the summaries. NewAggregator sets it to
+	// InfoLevel.
+	Level Level
+
+	// Interval is the period after which a summary is due. Summaries are
+	// not logged periodically if 0.
+	Interval time.Duration
+
+	// Updates is the number of Add and Observe calls after which a summary
+	// is due. Summaries are not logged per updates if 0.
+	Updates int
+
+	mu      sync.Mutex
+	start   time.Time
+	updates int
+	index   map[string]int
+	stats   []aggStat
+	// window counts the summaries, so that the timer of a window does not
+	// log the next one.
+	window uint64
+	timer  *time.Timer
+}
+
+// aggStat holds the accumulated values of a key.
+type aggStat struct {
+	key      string
+	observed bool
+	// mixed is set once the key got both kinds of updates.
+	mixed bool
+	count int64
+	total int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// aggSummary is a summary taken from an Aggregator, logged once its lock is
+// released.
+type aggSummary struct {
+	stats   []aggStat
+	elapsed time.Duration
+}
+
+// NewAggregator returns an Aggregator logging msg with l every interval.
+func NewAggregator(l Logger, msg string, interval time.Duration) *Aggregator {
+	return &Aggregator{Logger: l, Message: msg, Level: InfoLevel, Interval: interval}
+}
+
+// Add adds n to the sum of key.
+func (a *Aggregator) Add(key string, n int64) {
+	a.mu.Lock()
+	s, ok := a.stat(key, false)
+	if ok {
+		s.total += n
+	}
+	sum, due := a.update(ok)
+	a.mu.Unlock()
+
+	if due {
+		a.log(sum)
+	}
+}
+
+// Observe records the value v of key, such as the gas of a block, for its
+// count, sum, min and max.
+func (a *Aggregator) Observe(key string, v float64) {
+	a.mu.Lock()
+	s, ok := a.stat(key, true)
+	if ok {
+		if s.count == 0 || v < s.min {
+			s.min = v
+		}
+		if s.count == 0 || v > s.max {
+			s.max = v
+		}
+		s.count++
+		s.sum += v
+	}
+	sum, due := a.update(ok)
+	a.mu.Unlock()
+
+	if due {
+		a.log(sum)
+	}
+}
+
+// Flush logs a summary of the updates since the previous one, if any.
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	due := a.updates > 0
+	var sum aggSummary
+	if due {
+		sum = a.summary()
+	}
+	a.mu.Unlock()
+
+	if due {
+		a.log(sum)
+	}
+}
+
+// tick logs the summary of window, if it was not logged yet.
+func (a *Aggregator) tick(window uint64) {
+	a.mu.Lock()
+	due := a.window == window && a.updates > 0
+	var sum aggSummary
+	if due {
+		sum = a.summary()
+	}
+	a.mu.Unlock()
+
+	if due {
+		a.log(sum)
+	}
+}
+
+// stat returns the stat of key, created if needed, and whether it takes
+// updates of the observed kind. The lock must be held.
+func (a *Aggregator) stat(key string, observed bool) (*aggStat, bool) {
+	if a.index == nil {
+		a.index = make(map[string]int)
+	}
+	i, ok := a.index[key]
+	if !ok {
+		i = len(a.stats)
+		a.index[key] = i
+		a.stats = append(a.stats, aggStat{key: key, observed: observed})
+	}
+	s := &a.stats[i]
+	if s.observed != observed {
+		if !s.mixed {
+			s.mixed = true
+			reportWriterError(fmt.Errorf("aggregator key %q passed to both Add and Observe", key))
+		}
+		return s, false
+	}
+	return s, true
+}
+
+// update counts an update if ok, and takes a summary if one is due. The lock
+// must be held.
+func (a *Aggregator) update(ok bool) (aggSummary, bool) {
+	if !ok {
+		return aggSummary{}, false
+	}
+	if a.updates == 0 {
+		a.start = time.Now()
+	}
+	a.updates++
+	if (a.Updates > 0 && a.updates >= a.Updates) ||
+		(a.Interval > 0 && time.Since(a.start) >= a.Interval) {
+		return a.summary(), true
+	}
+	if a.updates == 1 && a.Interval > 0 {
+		window := a.window
+		a.timer = time.AfterFunc(a.Interval, func() {
+			a.tick(window)
+		})
+	}
+	return aggSummary{}, false
+}
+
+// summary takes a summary and resets the stats. The lock must be held.
+func (a *Aggregator) summary() aggSummary {
+	now := time.Now()
+	sum := aggSummary{
+		stats:   make([]aggStat, len(a.stats)),
+		elapsed: now.Sub(a.start),
+	}
+	copy(sum.stats, a.stats)
+	for i := range a.stats {
+		a.stats[i] = aggStat{key: a.stats[i].key, observed: a.stats[i].observed, mixed: a.stats[i].mixed}
+	}
+	a.updates = 0
+	a.window++
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	return sum
+}
+
+// log logs sum.
+func (a *Aggregator) log(sum aggSummary) {
+	e := a.Logger.WithLevel(a.Level)
+	if !e.Enabled() {
+		return
+	}
+	secs := sum.elapsed.Seconds()
+	for _, s := range sum.stats {
+		if !s.observed {
+			e.Int64(s.key, s.total)
+			if secs > 0 {
+				e.Float64(s.key+"_rate", float64(s.total)/secs)
+			}
+			continue
+		}
+		e.Int64(s.key+"_count", s.count)
+		e.Float64(s.key+"_sum", s.sum)
+		if s.count > 0 {
+			e.Float64(s.key+"_min", s.min)
+			e.Float64(s.key+"_max", s.max)
+		}
+		if secs > 0 {
+			e.Float64(s.key+"_rate", s.sum/secs)
+		}
+	}
+	e.Dur(ElapsedFieldName, sum.elapsed).Msg(a.Message)
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	var buf bytes.Buffer
+	agg := NewAggregator(NewWriter(&buf), "imported", 0)
+	agg.Updates = 100
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agg.Add("blocks", 1)
+			agg.Observe("gas", float64(i))
+		}()
+	}
+	wg.Wait()
+	agg.Add("blocks", 1)
+	agg.Flush()
+	agg.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d summaries, want 2:\n%s", len(lines), buf.String())
+	}
+	var first, second map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["level"] != "info" || first["message"] != "imported" || first["blocks"] != 50.0 ||
+		first["gas_count"] != 50.0 || first["gas_sum"] != 1275.0 ||
+		first["gas_min"] != 1.0 || first["gas_max"] != 50.0 ||
+		first["blocks_rate"] == nil || first["elapsed"] == nil {
+		t.Errorf("bad first summary: %v", first)
+	}
+	if second["blocks"] != 1.0 || second["gas_count"] != 0.0 || second["gas_min"] != nil {
+		t.Errorf("bad second summary: %v", second)
+	}
+}
+
+func TestAggregatorInterval(t *testing.T) {
+	var buf syncBuffer
+	agg := NewAggregator(NewWriter(&buf), "imported", 20*time.Millisecond)
+	agg.Add("blocks", 3)
+
+	// The window is logged without further updates nor Flush.
+	deadline := time.Now().Add(time.Second)
+	for buf.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(buf.String()), &m); err != nil {
+		t.Fatalf("no summary logged by the timer: %v", err)
+	}
+	if m["blocks"] != 3.0 {
+		t.Errorf("bad summary: %v", m)
+	}
+}
+
+func TestAggregatorIdle(t *testing.T) {
+	var buf bytes.Buffer
+	agg := NewAggregator(NewWriter(&buf), "imported", time.Hour)
+	agg.Add("blocks", 1)
+	agg.Flush()
+	buf.Reset()
+
+	// The idle time before the first update is not part of the window.
+	time.Sleep(50 * time.Millisecond)
+	agg.Add("blocks", 1)
+	agg.Flush()
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed, _ := m["elapsed"].(float64); elapsed >= 50 {
+		t.Errorf("elapsed = %v ms, want the time since the first update", elapsed)
+	}
+}
+
+func TestAggregatorMixedKey(t *testing.T) {
+	var errs []error
+	saved := ErrorHandler
+	ErrorHandler = func(err error) { errs = append(errs, err) }
+	defer func() { ErrorHandler = saved }()
+
+	var buf bytes.Buffer
+	agg := NewAggregator(NewWriter(&buf), "imported", 0)
+	agg.Add("blocks", 2)
+	agg.Observe("blocks", 5)
+	agg.Observe("blocks", 6)
+	agg.Flush()
+
+	if len(errs) != 1 {
+		t.Errorf("got %d errors, want 1: %v", len(errs), errs)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["blocks"] != 2.0 || m["blocks_count"] != nil {
+		t.Errorf("bad summary: %v", m)
+	}
+}
@@ -350,3 +350,43 @@ func BenchmarkContextFieldType(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkVmoduleDisabled(b *testing.B) {
+	defer SetVmodule("")
+	logger := NewWriter(io.Discard).Level(InfoLevel)
+	for _, spec := range []string{"", "other.go=info", "other.go=debug"} {
+		b.Run(spec, func(b *testing.B) {
+			if err := SetVmodule(spec); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				logger.Debug(fakeMessage)
+			}
+		})
+	}
+}
+
+func BenchmarkDynamicDebugDisabled(b *testing.B) {
+	SetDynamicDebug(true)
+	defer SetDynamicDebug(false)
+	logger := NewWriter(io.Discard).Level(InfoLevel)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug(fakeMessage)
+	}
+}
+
+// BenchmarkDebugDisabled measures a Debug call below the level of the logger
+// without any rule, which should cost one level comparison and one atomic
+// load.
+func BenchmarkDebugDisabled(b *testing.B) {
+	logger := NewWriter(io.Discard).Level(InfoLevel)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Debug(fakeMessage)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// diskBudgetWarnRatio is the share of the disk budget above which a one-time
+// warning is logged.
+const diskBudgetWarnRatio = 0.9
+
+// backupFilePattern matches the suffix of the backup files created by
+// lumberjack when it rotates a log file, e.g. "chain.C-2025-01-02T15-04-05.000.log.gz"
+// for "chain.C.log". The first group is the rotation timestamp.
+var backupFilePattern = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3})\.log(\.gz)?$`)
+
+// diskBudget caps the combined size of the current and backup log files in a
+// directory. Writes are accounted as they happen and the directory is scanned
+// once enough bytes have been written; when over budget, the oldest backups
+// are deleted first, across all loggers. Only the files being written and
+// their backups are accounted and deleted, other files of the directory are
+// left alone.
+type diskBudget struct {
+	dir        string
+	limit      int64
+	checkEvery int64
+	pending    atomic.Int64
+
+	// warn is called once when the usage first reaches diskBudgetWarnRatio of
+	// the limit.
+	warn func(used, limit int64)
+
+	mu      sync.Mutex
+	current map[string]bool // base names of the files being written
+	warned  bool
+}
+
+func newDiskBudget(dir string, limitMB int, warn func(used, limit int64)) *diskBudget {
+	limit := int64(limitMB) * 1024 * 1024
+	checkEvery := limit / 100
+	if checkEvery > 1024*1024 {
+		checkEvery = 1024 * 1024
+	}
+	return &diskBudget{
+		dir:        dir,
+		limit:      limit,
+		checkEvery: checkEvery,
+		warn:       warn,
+		current:    make(map[string]bool),
+	}
+}
+
+// track adds filename to the current files of the budget and enforces it.
+func (b *diskBudget) track(filename string) {
+	b.mu.Lock()
+	b.current[filepath.Base(filename)] = true
+	used, warn := b.enforce()
+	b.mu.Unlock()
+
+	if warn {
+		b.warn(used, b.limit)
+	}
+}
+
+// wrap returns a writer accounting the bytes written to w against the budget.
+func (b *diskBudget) wrap(w io.Writer) io.Writer {
+	return &budgetWriter{Writer: w, budget: b}
+}
+
+// add accounts n written bytes and enforces the budget once enough bytes have
+// been written since the last check.
+func (b *diskBudget) add(n int) {
+	if b.pending.Add(int64(n)) < b.checkEvery {
+		return
+	}
+	// Skip the check if another writer is already running it.
+	if !b.mu.TryLock() {
+		return
+	}
+	b.pending.Store(0)
+	used, warn := b.enforce()
+	b.mu.Unlock()
+
+	if warn {
+		b.warn(used, b.limit)
+	}
+}
+
+// backupOf returns the rotation timestamp of name if it is a backup of a file
+// being written.
+func (b *diskBudget) backupOf(name string) (timestamp string, ok bool) {
+	m := backupFilePattern.FindStringSubmatchIndex(name)
+	if m == nil || !b.current[name[:m[0]]+".log"] {
+		return "", false
+	}
+	return name[m[2]:m[3]], true
+}
+
+// enforce deletes the oldest backups while over budget, and returns the
+// bytes used and whether the warning must be emitted, which the caller does
+// once the lock is released. It expects lock to be held.
+func (b *diskBudget) enforce() (used int64, warn bool) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return 0, false
+	}
+
+	type backup struct {
+		name      string
+		timestamp string
+		size      int64
+	}
+	var backups []backup
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+		timestamp, isBackup := b.backupOf(name)
+		if !isBackup && !b.current[name] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		used += info.Size()
+		if isBackup {
+			backups = append(backups, backup{name: name, timestamp: timestamp, size: info.Size()})
+		}
+	}
+
+	if used > b.limit {
+		sort.Slice(backups, func(i, j int) bool {
+			return backups[i].timestamp < backups[j].timestamp
+		})
+		for _, f := range backups {
+			if used <= b.limit {
+				break
+			}
+			if err := os.Remove(filepath.Join(b.dir, f.name)); err == nil || os.IsNotExist(err) {
+				used -= f.size
+			}
+		}
+	}
+
+	if !b.warned && float64(used) >= diskBudgetWarnRatio*float64(b.limit) {
+		b.warned = true
+		return used, b.warn != nil
+	}
+	return used, false
+}
+
+// budgetWriter accounts the bytes written to a log file against a diskBudget.
+type budgetWriter struct {
+	io.Writer
+	budget *diskBudget
+}
+
+func (w *budgetWriter) Write(p []byte) (n int, err error) {
+	n, err = w.Writer.Write(p)
+	w.budget.add(n)
+	return n, err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (w *budgetWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
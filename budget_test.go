@@ -0,0 +1,76 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSizedFile(t *testing.T, dir, name string, size int) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskBudgetDeletesOldestBackups(t *testing.T) {
+	const kb = 1024
+	dir := t.TempDir()
+	writeSizedFile(t, dir, "chain.C.log", 300*kb)
+	writeSizedFile(t, dir, "chain.C-2025-01-01T00-00-00.000.log", 400*kb)
+	writeSizedFile(t, dir, "chain.C-2025-01-02T00-00-00.000.log.gz", 400*kb)
+	// Files the budget does not own are neither accounted nor deleted.
+	writeSizedFile(t, dir, "other-2024-01-01T00-00-00.000.log", 400*kb)
+	writeSizedFile(t, dir, "xchain.C-2024-01-01T00-00-00.000.log", 400*kb)
+	writeSizedFile(t, dir, "notes.txt", 400*kb)
+
+	warned := false
+	b := newDiskBudget(dir, 1, func(used, limit int64) { warned = true })
+	b.track(filepath.Join(dir, "chain.C.log"))
+
+	for name, kept := range map[string]bool{
+		"chain.C.log":                            true,
+		"chain.C-2025-01-01T00-00-00.000.log":    false,
+		"chain.C-2025-01-02T00-00-00.000.log.gz": true,
+		"other-2024-01-01T00-00-00.000.log":      true,
+		"xchain.C-2024-01-01T00-00-00.000.log":   true,
+		"notes.txt":                              true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != kept {
+			t.Errorf("%s exists = %v, want %v", name, exists, kept)
+		}
+	}
+	if warned {
+		t.Error("warned while 700KB of 1MB are used")
+	}
+}
+
+func TestDiskBudgetWarning(t *testing.T) {
+	dir := t.TempDir()
+	writeSizedFile(t, dir, "chain.C.log", 950*1024)
+
+	var b *diskBudget
+	calls := 0
+	b = newDiskBudget(dir, 1, func(used, limit int64) {
+		calls++
+		// The warning is logged without holding the lock, so it may end up
+		// in a file of the budget.
+		if !b.mu.TryLock() {
+			t.Error("warn called with the lock held")
+			return
+		}
+		b.mu.Unlock()
+		if used != 950*1024 || limit != 1024*1024 {
+			t.Errorf("warn(%d, %d)", used, limit)
+		}
+	})
+	b.track(filepath.Join(dir, "chain.C.log"))
+	b.track(filepath.Join(dir, "chain.C.log"))
+	if calls != 1 {
+		t.Errorf("warned %d times, want once", calls)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// callSite describes a program counter a log call was made from. Call sites
+// are resolved once and cached, so that per-site decisions stay cheap.
+type callSite struct {
+	pc       uintptr
+	file     string
+	line     int
+	function string // package path qualified function name
+	pkg      string // package path
+
+	// internal is set for frames of this package (outside of tests) and of
+	// the packages registered with RegisterInternalPackages.
+	internal bool
+
+	// vmodule caches the level set by the vmodule rules, see vmoduleRules.
+	vmodule atomic.Uint64
+
+	// dynamic is the state of the call site in the dynamic debug registry.
+	dynamic dynamicSite
+}
+
+// callSites holds the *callSite of each program counter, and internalPCs the
+// program counters of internal frames, which are skipped when looking for the
+// code calling the logger and not made call sites.
+var callSites, internalPCs sync.Map
+
+// logPackage is the import path of this package.
+var logPackage = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	pkg, _ := splitFunctionName(runtime.FuncForPC(pc).Name())
+	return pkg
+}()
+
+// splitFunctionName splits a qualified function name such as
+// "github.com/luxfi/log.(*logger).Trace" into its package path and name.
+func splitFunctionName(function string) (pkg, name string) {
+	slash := strings.LastIndexByte(function, '/')
+	dot := strings.IndexByte(function[slash+1:], '.')
+	if dot < 0 {
+		return "", function
+	}
+	dot += slash + 1
+	return function[:dot], function[dot+1:]
+}
+
+// lookupCallSite returns the call site of pc, resolving it on first use.
+func lookupCallSite(pc uintptr) *callSite {
+	if s, ok := callSites.Load(pc); ok {
+		return s.(*callSite)
+	}
+	s, _ := callSites.LoadOrStore(pc, resolveCallSite(pc))
+	return s.(*callSite)
+}
+
+func resolveCallSite(pc uintptr) *callSite {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	pkg, _ := splitFunctionName(frame.Function)
+	return &callSite{
+		pc:       pc,
+		file:     frame.File,
+		line:     frame.Line,
+		function: frame.Function,
+		pkg:      pkg,
+		internal: (pkg == logPackage && !strings.HasSuffix(frame.File, "_test.go")) ||
+			IsInternalPackage(pkg),
+	}
+}
+
+// callerFrames is the number of frames callerSite reads at a time. It covers
+// the internal frames of the usual logging calls, as unwinding the stack
+// costs per frame read.
+const callerFrames = 8
+
+// callerSite returns the first call site on the stack that is not internal,
+// or nil if there is none within a few frames.
+func callerSite() *callSite {
+	var pcs [callerFrames]uintptr
+	for skip := 2; skip < 2+2*callerFrames; skip += callerFrames {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			if _, ok := internalPCs.Load(pc); ok {
+				continue
+			}
+			var s *callSite
+			if v, ok := callSites.Load(pc); ok {
+				s = v.(*callSite)
+			} else if s = resolveCallSite(pc); !s.internal {
+				v, _ := callSites.LoadOrStore(pc, s)
+				s = v.(*callSite)
+			}
+			if !s.internal {
+				return s
+			}
+			internalPCs.Store(pc, struct{}{})
+		}
+		if n < len(pcs) {
+			break
+		}
+	}
+	return nil
+}
+
+// siteAt returns the call site of pc, or callerSite if pc is 0.
+func siteAt(pc uintptr) *callSite {
+	if pc != 0 {
+		return lookupCallSite(pc)
+	}
+	return callerSite()
+}
+
+// callSiteEnabled reports whether the code at pc, or the code calling the
+// logger if pc is 0, is enabled for lvl by the vmodule rules or the dynamic
+// debug registry. It records the call site in the registry as a side effect.
+func callSiteEnabled(lvl Level, pc uintptr) bool {
+	rules := vmodule.Load()
+	if rules != nil && lvl < rules.min {
+		rules = nil
+	}
+	dynamic := lvl <= DebugLevel && dynamicDebug.Load()
+	if rules == nil && !dynamic {
+		return false
+	}
+	s := siteAt(pc)
+	if s == nil {
+		return false
+	}
+	if dynamic {
+		s.record(lvl)
+		if s.dynamic.enabled.Load() {
+			return true
+		}
+	}
+	if rules != nil {
+		minLevel, ok := s.vmoduleLevel(rules)
+		return ok && lvl >= minLevel
+	}
+	return false
+}
@@ -202,8 +202,10 @@ func (c Context) Err(err error) Context {
 // Ctx adds the context.Context to the logger context. The context.Context is
 // not rendered in the error message, but is made available for hooks to use.
 // A typical use case is to extract tracing information from the
-// context.Context.
+// context.Context. The fields attached to ctx with ContextWith are added to
+// the logger context, but those it already got from the context it had.
 func (c Context) Ctx(ctx context.Context) Context {
+	c.l.context = appendCtxFields(c.l.context, ctx, ctxFields(c.l.ctx))
 	c.l.ctx = ctx
 	return c
 }
@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"slices"
 )
 
 // disabledLogger is a singleton disabled logger for context operations.
@@ -20,15 +21,8 @@ type ctxKey struct{}
 // WithContext returns a copy of ctx with the logger attached. The Logger
 // attached to the provided Context (if any) will not be affected.
 //
-// Note: to modify the existing Logger attached to a Context (instead of
-// replacing it in a new Context), use UpdateContext with the following
-// notation:
-//
-//	ctx := r.Context()
-//	l := logger.Ctx(ctx)
-//	l.UpdateContext(func(c Context) Context {
-//	    return c.Str("bar", "baz")
-//	})
+// To add fields to the events logged for a request without replacing the
+// attached logger, use ContextWith.
 func WithContext(ctx context.Context, l Logger) context.Context {
 	if l == nil {
 		l = Noop()
@@ -47,3 +41,124 @@ func Ctx(ctx context.Context) Logger {
 	}
 	return disabledLogger
 }
+
+type ctxFieldsKey struct{}
+
+// ctxField is a field attached to a context with ContextWith, encoded alone
+// as an unterminated object.
+type ctxField struct {
+	key string
+	buf []byte
+}
+
+// ctxFieldSet holds the fields attached to a context with ContextWith, with
+// the newest value of each key. The fields it shares with the set of a parent
+// context are the same *ctxField, so that those already logged are known.
+type ctxFieldSet struct {
+	fields []*ctxField
+	buf    []byte // all the fields, encoded as an unterminated object
+}
+
+// contains reports whether f belongs to s.
+func (s *ctxFieldSet) contains(f *ctxField) bool {
+	if s == nil {
+		return false
+	}
+	for _, sf := range s.fields {
+		if sf == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ContextWith returns a copy of ctx carrying the key-value pairs kv as log
+// fields, in addition to the fields already attached to ctx, whose values are
+// replaced by those of kv for the same keys. The fields are encoded once, then
+// merged into the events given ctx with Event.Ctx and the loggers given ctx
+// with Logger.Ctx or Context.Ctx, so request-scoped identifiers flow through
+// call stacks without passing loggers around. The fields a logger already
+// got from ctx are not added again to its events. Keys must be strings, as
+// for Context.Fields.
+func ContextWith(ctx context.Context, kv ...interface{}) context.Context {
+	if len(kv) < 2 {
+		return ctx
+	}
+	var fields []*ctxField
+	if parent := ctxFields(ctx); parent != nil {
+		fields = make([]*ctxField, len(parent.fields), len(parent.fields)+len(kv)/2)
+		copy(fields, parent.fields)
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		f := &ctxField{
+			key: key,
+			buf: appendFieldList(enc.AppendBeginMarker(nil), kv[i:i+2], false, nil, nil),
+		}
+		if j := slices.IndexFunc(fields, func(f *ctxField) bool { return f.key == key }); j >= 0 {
+			fields[j] = f
+		} else {
+			fields = append(fields, f)
+		}
+	}
+
+	set := &ctxFieldSet{fields: fields, buf: enc.AppendBeginMarker(nil)}
+	for _, f := range fields {
+		set.buf = enc.AppendObjectData(set.buf, f.buf)
+	}
+	return context.WithValue(ctx, ctxFieldsKey{}, set)
+}
+
+// ctxFields returns the fields attached to ctx with ContextWith.
+func ctxFields(ctx context.Context) *ctxFieldSet {
+	if ctx == nil {
+		return nil
+	}
+	set, _ := ctx.Value(ctxFieldsKey{}).(*ctxFieldSet)
+	return set
+}
+
+// appendCtxFields appends the fields attached to ctx with ContextWith to dst,
+// an unterminated object, but those of applied, already in dst.
+func appendCtxFields(dst []byte, ctx context.Context, applied *ctxFieldSet) []byte {
+	set := ctxFields(ctx)
+	if set == nil || set == applied || len(set.buf) <= 1 {
+		return dst
+	}
+	if applied == nil {
+		return enc.AppendObjectData(dst, set.buf)
+	}
+	for _, f := range set.fields {
+		if !applied.contains(f) {
+			dst = enc.AppendObjectData(dst, f.buf)
+		}
+	}
+	return dst
+}
+
+type forcedLevelKey struct{}
+
+// WithForcedLevel returns a copy of ctx forcing the events at lvl or above to
+// be logged, whatever the logger and global levels and sampler, so that a
+// single request or operation can be traced, for instance by middleware
+// trusting a signed header. The forced events carry the ForcedFieldName
+// field. Disabled loggers, and a Disabled global level, still drop them.
+//
+// The level is checked when the event is created, so ctx must reach the
+// logger first, with Logger.Ctx, Context.Ctx or a slog handler from
+// SlogHandler; Event.Ctx cannot revive an event that was dropped.
+func WithForcedLevel(ctx context.Context, lvl Level) context.Context {
+	return context.WithValue(ctx, forcedLevelKey{}, lvl)
+}
+
+// ForcedLevel returns the level forced by ctx with WithForcedLevel.
+func ForcedLevel(ctx context.Context) (Level, bool) {
+	if ctx == nil {
+		return NoLevel, false
+	}
+	lvl, ok := ctx.Value(forcedLevelKey{}).(Level)
+	return lvl, ok
+}
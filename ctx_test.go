@@ -0,0 +1,86 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestContextWith(t *testing.T) {
+	ctx := ContextWith(context.Background(), "request_id", "r1")
+	ctx = ContextWith(ctx, "user", 7)
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf)
+	l.InfoEvent().Str("a", "b").Ctx(ctx).Msg("event")
+	l.Ctx(ctx).Info("logger")
+	l.InfoEvent().Ctx(context.Background()).Msg("none")
+
+	want := `{"level":"info","a":"b","request_id":"r1","user":7,"message":"event"}` + "\n" +
+		`{"level":"info","request_id":"r1","user":7,"message":"logger"}` + "\n" +
+		`{"level":"info","message":"none"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestContextWithDuplicates(t *testing.T) {
+	saved := CallerFieldName
+	CallerFieldName = ""
+	defer func() { CallerFieldName = saved }()
+
+	ctx := ContextWith(context.Background(), "id", 1, "user", "u")
+	ctx = ContextWith(ctx, "id", 2)
+	ctx2 := ContextWith(ctx, "extra", true)
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Ctx(ctx)
+	l.InfoEvent().Ctx(ctx).Msg("same")
+	l.WithLevel(InfoLevel).Ctx(ctx2).Ctx(ctx2).Msg("child")
+	if err := SlogHandler(l).Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "slog", 0)); err != nil {
+		t.Fatal(err)
+	}
+	l.Ctx(ctx2).Info("logger")
+
+	want := `{"level":"info","id":2,"user":"u","message":"same"}` + "\n" +
+		`{"level":"info","id":2,"user":"u","extra":true,"message":"child"}` + "\n" +
+		`{"level":"info","id":2,"user":"u","message":"slog"}` + "\n" +
+		`{"level":"info","id":2,"user":"u","extra":true,"message":"logger"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWithForcedLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel).Sample(RandomSampler(0))
+	ctx := WithForcedLevel(context.Background(), DebugLevel)
+
+	l.Debug("dropped")
+	l.Ctx(ctx).Trace("below forced level")
+	l.Ctx(ctx).Debug("forced", "a", 1)
+	if !l.Enabled(ctx, slog.LevelDebug) || l.Enabled(ctx, slogLevelTrace) {
+		t.Error("Enabled does not honor the forced level")
+	}
+	h := SlogHandler(l)
+	if err := h.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelDebug, "slog", 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sampler is bypassed, but the event is not marked as forced.
+	l.Ctx(ctx).Info("sampled")
+	// Event.Ctx cannot force the level of an event already dropped.
+	l.DebugEvent().Ctx(ctx).Msg("event")
+
+	want := `{"level":"debug","forced":true,"a":1,"message":"forced"}` + "\n" +
+		`{"level":"debug","forced":true,"message":"slog"}` + "\n" +
+		`{"level":"info","message":"sampled"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CallSite describes a Trace or Debug call site recorded by the dynamic debug
+// registry.
+type CallSite struct {
+	PC       uintptr `json:"pc"`
+	File     string  `json:"file"`
+	Line     int     `json:"line"`
+	Function string  `json:"function"`
+	// Level is the level of the last event logged from the call site.
+	Level Level `json:"level"`
+	// Enabled is set if the call site was turned on with EnableCallSites.
+	Enabled bool `json:"enabled"`
+	// Count is the number of times the call site was executed since it was
+	// recorded.
+	Count uint64 `json:"count"`
+}
+
+// dynamicSite holds the dynamic debug state of a callSite.
+type dynamicSite struct {
+	recorded atomic.Bool
+	enabled  atomic.Bool
+	level    atomic.Int32
+	count    atomic.Uint64
+}
+
+// dynamicRule is a call site spec passed to EnableCallSites or
+// DisableCallSites.
+type dynamicRule struct {
+	spec    string
+	match   func(s *callSite) bool
+	enabled bool
+}
+
+var (
+	dynamicDebug   atomic.Bool
+	dynamicSitesMu sync.Mutex
+	dynamicSites   []*callSite
+	dynamicRules   []dynamicRule
+)
+
+// SetDynamicDebug turns the dynamic debug registry on or off, in the spirit
+// of the Linux kernel's dynamic_debug. While on, every Trace and Debug call
+// site that executes is recorded, whether its event is enabled or not, and
+// can be listed with CallSites and turned on individually with
+// EnableCallSites, without changing the level of the logger. Recording costs
+// a stack walk of a few frames per Trace and Debug call, enabled or not, on
+// the order of a microsecond, so it is off by default. While off,
+// call sites are neither recorded nor honored, but keep their state.
+func SetDynamicDebug(on bool) {
+	dynamicDebug.Store(on)
+	updateLevelOverrides()
+}
+
+// record adds the call site to the dynamic debug registry.
+func (s *callSite) record(lvl Level) {
+	s.dynamic.level.Store(int32(lvl))
+	s.dynamic.count.Add(1)
+	if s.dynamic.recorded.Load() || !s.dynamic.recorded.CompareAndSwap(false, true) {
+		return
+	}
+	dynamicSitesMu.Lock()
+	defer dynamicSitesMu.Unlock()
+
+	// A source line may have several program counters, for instance when it
+	// is inlined, so the specs also apply to the sites recorded after them.
+	for _, rule := range dynamicRules {
+		if rule.match(s) {
+			s.dynamic.enabled.Store(rule.enabled)
+		}
+	}
+	dynamicSites = append(dynamicSites, s)
+}
+
+// CallSites returns the call sites recorded by the dynamic debug registry,
+// sorted by file and line.
+func CallSites() []CallSite {
+	dynamicSitesMu.Lock()
+	sites := make([]CallSite, 0, len(dynamicSites))
+	for _, s := range dynamicSites {
+		sites = append(sites, CallSite{
+			PC:       s.pc,
+			File:     s.file,
+			Line:     s.line,
+			Function: s.function,
+			Level:    Level(s.dynamic.level.Load()),
+			Enabled:  s.dynamic.enabled.Load(),
+			Count:    s.dynamic.count.Load(),
+		})
+	}
+	dynamicSitesMu.Unlock()
+
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].File != sites[j].File {
+			return sites[i].File < sites[j].File
+		}
+		return sites[i].Line < sites[j].Line
+	})
+	return sites
+}
+
+// EnableCallSites turns on the call sites matching spec and returns how many
+// of the recorded ones matched. Matching call sites recorded later are turned
+// on as well. An enabled call site logs its Trace and Debug events regardless
+// of the logger and global levels, unless logging is disabled.
+//
+// spec is either a file, optionally followed by a line, such as
+// "chain/processor.go:412", matched against the end of the file path, or a
+// function, such as "chain.(*Processor).Process", matched against the end of
+// the package qualified function name.
+func EnableCallSites(spec string) (int, error) {
+	return setCallSites(spec, true)
+}
+
+// DisableCallSites turns off the recorded call sites matching spec, see
+// EnableCallSites, and returns how many matched.
+func DisableCallSites(spec string) (int, error) {
+	return setCallSites(spec, false)
+}
+
+func setCallSites(spec string, enabled bool) (int, error) {
+	spec = strings.TrimSpace(spec)
+	match, err := callSiteMatcher(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	dynamicSitesMu.Lock()
+	defer dynamicSitesMu.Unlock()
+
+	// A later spec overrides an earlier one for the sites matching both, so
+	// repeating a spec replaces its rule, keeping the rules few.
+	rule := dynamicRule{spec: spec, match: match, enabled: enabled}
+	dynamicRules = slices.DeleteFunc(dynamicRules, func(r dynamicRule) bool { return r.spec == spec })
+	dynamicRules = append(dynamicRules, rule)
+	n := 0
+	for _, s := range dynamicSites {
+		if match(s) {
+			s.dynamic.enabled.Store(enabled)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func callSiteMatcher(spec string) (func(s *callSite) bool, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty call site spec")
+	}
+
+	file, lineStr, hasLine := strings.Cut(spec, ":")
+	if strings.HasSuffix(file, ".go") {
+		line := 0
+		if hasLine {
+			var err error
+			if line, err = strconv.Atoi(lineStr); err != nil || line <= 0 {
+				return nil, fmt.Errorf("invalid line in call site spec %q", spec)
+			}
+		}
+		return func(s *callSite) bool {
+			return pathHasSuffix(s.file, file) && (line == 0 || s.line == line)
+		}, nil
+	}
+
+	return func(s *callSite) bool {
+		if !strings.HasSuffix(s.function, spec) {
+			return false
+		}
+		rest := s.function[:len(s.function)-len(spec)]
+		return rest == "" || strings.HasSuffix(rest, "/") || strings.HasSuffix(rest, ".")
+	}, nil
+}
+
+// pathHasSuffix reports whether suffix is a trailing sequence of path
+// elements of p.
+func pathHasSuffix(p, suffix string) bool {
+	if !strings.HasSuffix(p, suffix) {
+		return false
+	}
+	rest := p[:len(p)-len(suffix)]
+	return rest == "" || strings.HasSuffix(rest, "/") || strings.HasPrefix(suffix, "/")
+}
+
+// DynamicDebugHandler returns an http.Handler for the dynamic debug registry.
+// GET lists the recorded call sites as JSON. POST turns the call sites
+// matching the "site" form value on or off, according to the "enabled" form
+// value (true by default), and responds with the number of matching sites.
+func DynamicDebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(CallSites())
+		case http.MethodPost:
+			enabled := true
+			if s := r.FormValue("enabled"); s != "" {
+				var err error
+				if enabled, err = strconv.ParseBool(s); err != nil {
+					http.Error(w, "invalid enabled value: "+s, http.StatusBadRequest)
+					return
+				}
+			}
+			n, err := setCallSites(r.FormValue("site"), enabled)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]int{"matched": n})
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDynamicDebug(t *testing.T) {
+	SetDynamicDebug(true)
+	defer SetDynamicDebug(false)
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel)
+
+	debugSite := func() { l.Debug("site") }
+	debugSite()
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected output before enabling: %q", buf.String())
+	}
+
+	n, err := EnableCallSites("dyndebug_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == 0 {
+		t.Fatal("no call site matched")
+	}
+	debugSite()
+	if want := `{"level":"debug","message":"site"}` + "\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	if _, err := DisableCallSites("dyndebug_test.go"); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	debugSite()
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output after disabling: %q", buf.String())
+	}
+
+	for range 3 {
+		_, _ = EnableCallSites("dyndebug_test.go")
+		_, _ = DisableCallSites(" dyndebug_test.go")
+	}
+	dynamicSitesMu.Lock()
+	rules := len(dynamicRules)
+	dynamicSitesMu.Unlock()
+	if rules != 1 {
+		t.Errorf("%d rules after toggling the same spec, want 1", rules)
+	}
+
+	if _, err := EnableCallSites("dyndebug_test.go:x"); err == nil {
+		t.Error("expected an error for an invalid line")
+	}
+}
@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net"
 	"os"
 	"runtime"
@@ -29,6 +30,10 @@ type Event struct {
 	ch        []Hook          // hooks from context
 	skipFrame int             // The number of additional frames to skip when printing the caller.
 	ctx       context.Context // Optional Go context for event
+	time      time.Time       // Time of the event if set, instead of TimestampFunc()
+	applied   *ctxFieldSet    // Fields of a context already in buf, see ContextWith
+	typed     bool            // Whether marks are recorded, for slog writers
+	marks     []slogMark      // Typed values of fields in buf, see slogWriter
 }
 
 func putEvent(e *Event) {
@@ -67,9 +72,21 @@ func newEvent(w LevelWriter, level Level, stack bool, ctx context.Context, hooks
 	e.w = w
 	e.level = level
 	e.skipFrame = 0
+	e.time = time.Time{}
+	e.applied = nil
+	_, e.typed = w.(*slogWriter)
+	if len(e.marks) > 0 {
+		clear(e.marks)
+		e.marks = e.marks[:0]
+	}
 	return e
 }
 
+// mark records a as the typed value of the field encoded in buf from start.
+func (e *Event) mark(start int, a slog.Attr) {
+	e.marks = append(e.marks, slogMark{start: start, end: len(e.buf), attr: a})
+}
+
 func (e *Event) write() (err error) {
 	if e == nil {
 		return nil
@@ -77,7 +94,9 @@ func (e *Event) write() (err error) {
 	if e.level != Disabled {
 		e.buf = enc.AppendEndMarker(e.buf)
 		e.buf = enc.AppendLineBreak(e.buf)
-		if e.w != nil {
+		if w, ok := e.w.(levelCtxWriter); ok {
+			_, err = w.writeLevelCtx(e.GetCtx(), e.level, e.buf, e.marks)
+		} else if e.w != nil {
 			_, err = e.w.WriteLevel(e.level, e.buf)
 		}
 	}
@@ -144,7 +163,11 @@ func (e *Event) msg(msg string) {
 		hook.Run(e, e.level, msg)
 	}
 	if msg != "" {
+		start := len(e.buf)
 		e.buf = enc.AppendString(enc.AppendKey(e.buf, MessageFieldName), msg)
+		if e.typed {
+			e.mark(start, slog.String(MessageFieldName, msg))
+		}
 	}
 	if e.done != nil {
 		defer e.done(msg)
@@ -176,7 +199,13 @@ func (e *Event) Dict(key string, dict *Event) *Event {
 		return e
 	}
 	dict.buf = enc.AppendEndMarker(dict.buf)
+	start := len(e.buf)
 	e.buf = append(enc.AppendKey(e.buf, key), dict.buf...)
+	if e.typed && dict.typed {
+		if attrs, err := slogAttrs(nil, dict.buf, dict.marks); err == nil {
+			e.mark(start, slog.Attr{Key: key, Value: slog.GroupValue(attrs...)})
+		}
+	}
 	putEvent(dict)
 	return e
 }
@@ -189,7 +218,9 @@ func (e *Event) CreateDict() *Event {
 	if e == nil {
 		return newEvent(nil, DebugLevel, false, nil, nil)
 	}
-	return newEvent(nil, DebugLevel, e.stack, e.ctx, e.ch)
+	d := newEvent(nil, DebugLevel, e.stack, e.ctx, e.ch)
+	d.typed = e.typed
+	return d
 }
 
 // Dict creates an Event to be used with the *Event.Dict method.
@@ -298,7 +329,11 @@ func (e *Event) Str(key, val string) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendString(enc.AppendKey(e.buf, key), val)
+	if e.typed {
+		e.mark(start, slog.String(key, val))
+	}
 	return e
 }
 
@@ -317,6 +352,9 @@ func (e *Event) Stringer(key string, val fmt.Stringer) *Event {
 	if e == nil {
 		return e
 	}
+	if e.typed && !isNilValue(val) {
+		return e.Str(key, val.String())
+	}
 	e.buf = enc.AppendStringer(enc.AppendKey(e.buf, key), val)
 	return e
 }
@@ -392,7 +430,12 @@ func (e *Event) AnErr(key string, err error) *Event {
 		if isNilValue(m) {
 			return e
 		}
-		return e.Str(key, m.Error())
+		start := len(e.buf)
+		e.buf = enc.AppendString(enc.AppendKey(e.buf, key), m.Error())
+		if e.typed {
+			e.mark(start, slog.Any(key, m))
+		}
+		return e
 	case string:
 		return e.Str(key, m)
 	default:
@@ -454,10 +497,17 @@ func (e *Event) Stack() *Event {
 // Ctx adds the Go Context to the *Event context.  The context is not rendered
 // in the output message, but is available to hooks and to Func() calls via the
 // GetCtx() accessor. A typical use case is to extract tracing information from
-// the Go Ctx.
+// the Go Ctx. The fields attached to ctx with ContextWith are added to the
+// event, but those its logger, or a previous call, already added. A level
+// forced by ctx with WithForcedLevel does not apply, as the level of the
+// event was already checked: give ctx to the logger instead.
 func (e *Event) Ctx(ctx context.Context) *Event {
 	if e != nil {
 		e.ctx = ctx
+		e.buf = appendCtxFields(e.buf, ctx, e.applied)
+		if set := ctxFields(ctx); set != nil {
+			e.applied = set
+		}
 	}
 	return e
 }
@@ -478,7 +528,11 @@ func (e *Event) Bool(key string, b bool) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendBool(enc.AppendKey(e.buf, key), b)
+	if e.typed {
+		e.mark(start, slog.Bool(key, b))
+	}
 	return e
 }
 
@@ -496,7 +550,11 @@ func (e *Event) Int(key string, i int) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendInt(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Int(key, i))
+	}
 	return e
 }
 
@@ -514,7 +572,11 @@ func (e *Event) Int8(key string, i int8) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendInt8(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Int64(key, int64(i)))
+	}
 	return e
 }
 
@@ -532,7 +594,11 @@ func (e *Event) Int16(key string, i int16) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendInt16(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Int64(key, int64(i)))
+	}
 	return e
 }
 
@@ -550,7 +616,11 @@ func (e *Event) Int32(key string, i int32) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendInt32(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Int64(key, int64(i)))
+	}
 	return e
 }
 
@@ -568,7 +638,11 @@ func (e *Event) Int64(key string, i int64) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendInt64(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Int64(key, i))
+	}
 	return e
 }
 
@@ -586,7 +660,11 @@ func (e *Event) Uint(key string, i uint) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendUint(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Uint64(key, uint64(i)))
+	}
 	return e
 }
 
@@ -604,7 +682,11 @@ func (e *Event) Uint8(key string, i uint8) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendUint8(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Uint64(key, uint64(i)))
+	}
 	return e
 }
 
@@ -622,7 +704,11 @@ func (e *Event) Uint16(key string, i uint16) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendUint16(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Uint64(key, uint64(i)))
+	}
 	return e
 }
 
@@ -640,7 +726,11 @@ func (e *Event) Uint32(key string, i uint32) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendUint32(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Uint64(key, uint64(i)))
+	}
 	return e
 }
 
@@ -658,7 +748,11 @@ func (e *Event) Uint64(key string, i uint64) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendUint64(enc.AppendKey(e.buf, key), i)
+	if e.typed {
+		e.mark(start, slog.Uint64(key, i))
+	}
 	return e
 }
 
@@ -676,7 +770,11 @@ func (e *Event) Float32(key string, f float32) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendFloat32(enc.AppendKey(e.buf, key), f, FloatingPointPrecision)
+	if e.typed {
+		e.mark(start, slog.Float64(key, float64(f)))
+	}
 	return e
 }
 
@@ -694,7 +792,11 @@ func (e *Event) Float64(key string, f float64) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendFloat64(enc.AppendKey(e.buf, key), f, FloatingPointPrecision)
+	if e.typed {
+		e.mark(start, slog.Float64(key, f))
+	}
 	return e
 }
 
@@ -716,7 +818,11 @@ func (e *Event) Timestamp() *Event {
 	if e == nil {
 		return e
 	}
-	e.buf = enc.AppendTime(enc.AppendKey(e.buf, TimestampFieldName), TimestampFunc(), TimeFieldFormat)
+	t := e.time
+	if t.IsZero() {
+		t = TimestampFunc()
+	}
+	e.buf = enc.AppendTime(enc.AppendKey(e.buf, TimestampFieldName), t, TimeFieldFormat)
 	return e
 }
 
@@ -725,7 +831,11 @@ func (e *Event) Time(key string, t time.Time) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendTime(enc.AppendKey(e.buf, key), t, TimeFieldFormat)
+	if e.typed {
+		e.mark(start, slog.Time(key, t))
+	}
 	return e
 }
 
@@ -745,7 +855,11 @@ func (e *Event) Dur(key string, d time.Duration) *Event {
 	if e == nil {
 		return e
 	}
+	start := len(e.buf)
 	e.buf = enc.AppendDuration(enc.AppendKey(e.buf, key), d, DurationFieldUnit, DurationFieldFormat, DurationFieldInteger, FloatingPointPrecision)
+	if e.typed {
+		e.mark(start, slog.Duration(key, d))
+	}
 	return e
 }
 
@@ -771,8 +885,7 @@ func (e *Event) TimeDiff(key string, t time.Time, start time.Time) *Event {
 	if t.After(start) {
 		d = t.Sub(start)
 	}
-	e.buf = enc.AppendDuration(enc.AppendKey(e.buf, key), d, DurationFieldUnit, DurationFieldFormat, DurationFieldInteger, FloatingPointPrecision)
-	return e
+	return e.Dur(key, d)
 }
 
 // Any is a wrapper around Event.Interface.
@@ -0,0 +1,195 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type fatalHook struct {
+	id uint64
+	fn func()
+}
+
+var (
+	fatalHooksMu  sync.Mutex
+	fatalHooks    []fatalHook
+	nextFatalHook uint64
+)
+
+// OnFatal registers fn to run before the process exits because of a Fatal
+// event, for instance to flush a database. Hooks run in registration order,
+// after the writer of the logger was synced, and the process exits anyway
+// once FatalHookTimeout elapses. The returned function unregisters fn.
+func OnFatal(fn func()) (cancel func()) {
+	fatalHooksMu.Lock()
+	defer fatalHooksMu.Unlock()
+
+	id := nextFatalHook
+	nextFatalHook++
+	fatalHooks = append(fatalHooks, fatalHook{id: id, fn: fn})
+	return func() {
+		fatalHooksMu.Lock()
+		defer fatalHooksMu.Unlock()
+		for i, h := range fatalHooks {
+			if h.id == id {
+				fatalHooks = append(fatalHooks[:i:i], fatalHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// runFatalHooks runs the OnFatal hooks, giving up after FatalHookTimeout.
+func runFatalHooks() {
+	fatalHooksMu.Lock()
+	hooks := make([]func(), 0, len(fatalHooks))
+	for _, h := range fatalHooks {
+		hooks = append(hooks, h.fn)
+	}
+	fatalHooksMu.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, fn := range hooks {
+			runFatalHook(fn)
+		}
+	}()
+	timer := time.NewTimer(FatalHookTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		reportWriterError(fmt.Errorf("OnFatal hooks did not finish within %v", FatalHookTimeout))
+	}
+}
+
+func runFatalHook(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportWriterError(fmt.Errorf("OnFatal hook panicked: %v", r))
+		}
+	}()
+	fn()
+}
+
+// fatalExit ends the process after a Fatal event was written to w: it syncs
+// w, runs the OnFatal hooks, closes w but the standard streams it writes to,
+// and calls exit, or ExitFunc if exit is nil, with code 1.
+func fatalExit(w io.Writer, exit func(code int)) {
+	if w != nil {
+		_ = syncOutput(w)
+	}
+	runFatalHooks()
+	closeOutput(w)
+	if exit == nil {
+		exit = ExitFunc
+	}
+	exit(1)
+}
+
+// closeOutput closes w if it is an io.Closer, except for os.Stdout and
+// os.Stderr, which stay open for the hooks and deferred code still writing to
+// them. The writers of this package wrapping others are closed writer by
+// writer.
+func closeOutput(w io.Writer) {
+	switch w := w.(type) {
+	case *os.File:
+		if w == os.Stdout || w == os.Stderr {
+			return
+		}
+	case LevelWriterAdapter:
+		closeOutput(w.Writer)
+		return
+	case multiLevelWriter:
+		for _, lw := range w.writers {
+			closeOutput(lw)
+		}
+		return
+	case ConsoleWriter:
+		closeOutput(w.Out)
+		return
+	}
+	if closer, ok := w.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// ExitFunc sets the function called instead of the global ExitFunc to end the
+// process after a Fatal event of the logger.
+func (c Context) ExitFunc(fn func(code int)) Context {
+	c.l.exit = fn
+	return c
+}
+
+// FatalError is the value PanicOnFatal loggers panic with instead of exiting.
+type FatalError struct {
+	// Code is the exit code the process would have exited with.
+	Code int
+	// Message is the message of the Fatal event.
+	Message string
+	// Fields holds the other fields of the event, decoded from JSON.
+	Fields map[string]interface{}
+}
+
+func (e *FatalError) Error() string {
+	return fmt.Sprintf("fatal: %s", e.Message)
+}
+
+// PanicOnFatal returns a child of l whose Fatal events, once written and the
+// OnFatal hooks have run, panic with a *FatalError instead of exiting, so
+// that tests can recover it and check the message and fields. Loggers not
+// created by this package are returned as is.
+func PanicOnFatal(l Logger) Logger {
+	ll, ok := resolveRoot(l).(*logger)
+	if !ok {
+		return l
+	}
+	c := ll.With()
+	capture := &fatalCapture{LevelWriter: c.l.w}
+	c.l.w = capture
+	return c.ExitFunc(capture.panic).Logger()
+}
+
+// fatalCapture keeps the last Fatal event written through it.
+type fatalCapture struct {
+	LevelWriter
+
+	mu   sync.Mutex
+	last []byte
+}
+
+func (w *fatalCapture) WriteLevel(level Level, p []byte) (int, error) {
+	if level == FatalLevel {
+		w.mu.Lock()
+		w.last = append(w.last[:0], p...)
+		w.mu.Unlock()
+	}
+	return w.LevelWriter.WriteLevel(level, p)
+}
+
+func (w *fatalCapture) panic(code int) {
+	w.mu.Lock()
+	line := bytes.Clone(w.last)
+	w.mu.Unlock()
+
+	fe := &FatalError{Code: code, Fields: map[string]interface{}{}}
+	_ = json.Unmarshal(line, &fe.Fields)
+	if msg, ok := fe.Fields[MessageFieldName].(string); ok {
+		fe.Message = msg
+	}
+	delete(fe.Fields, MessageFieldName)
+	delete(fe.Fields, LevelFieldName)
+	panic(fe)
+}
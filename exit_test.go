@@ -0,0 +1,91 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestPanicOnFatal(t *testing.T) {
+	var hooked bool
+	cancel := OnFatal(func() { hooked = true })
+	defer cancel()
+
+	var buf bytes.Buffer
+	l := PanicOnFatal(NewWriter(&buf).With().Str("component", "db").Logger())
+
+	defer func() {
+		fe, ok := recover().(*FatalError)
+		if !ok {
+			t.Fatal("expected a *FatalError panic")
+		}
+		if fe.Code != 1 || fe.Message != "corrupt" || fe.Fields["component"] != "db" || fe.Fields["n"] != float64(3) {
+			t.Errorf("unexpected fatal error %+v", fe)
+		}
+		if !hooked {
+			t.Error("OnFatal hook did not run")
+		}
+		if want := `{"level":"fatal","component":"db","n":3,"message":"corrupt"}` + "\n"; buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	}()
+	l.Fatal("corrupt", "n", 3)
+}
+
+func TestExitFunc(t *testing.T) {
+	saved := ExitFunc
+	defer func() { ExitFunc = saved }()
+
+	var code int
+	ExitFunc = func(c int) { code = c }
+	NewWriter(&bytes.Buffer{}).FatalEvent().Msg("exit")
+	if code != 1 {
+		t.Errorf("got exit code %d, want 1", code)
+	}
+}
+
+// outputRecorder is a writer recording whether it was synced and closed.
+type outputRecorder struct {
+	bytes.Buffer
+	synced, closed bool
+}
+
+func (w *outputRecorder) Sync() error {
+	w.synced = true
+	return nil
+}
+
+func (w *outputRecorder) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestFatalExitKeepsStdStreamsOpen(t *testing.T) {
+	var code int
+	w := &outputRecorder{}
+	fatalExit(MultiLevelWriter(os.Stderr, LevelWriterAdapter{os.Stdout}, w), func(c int) { code = c })
+	if code != 1 || !w.synced || !w.closed {
+		t.Errorf("code = %d, synced = %v, closed = %v, want 1, true, true", code, w.synced, w.closed)
+	}
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if _, err := f.Stat(); err != nil {
+			t.Errorf("%s was closed: %v", f.Name(), err)
+		}
+	}
+}
+
+func TestSlogCritSyncs(t *testing.T) {
+	saved := ExitFunc
+	defer func() { ExitFunc = saved }()
+	var code int
+	ExitFunc = func(c int) { code = c }
+
+	w := &outputRecorder{}
+	NewLogger(NewGlogHandler(NewTerminalHandler(w, false))).Crit("crit")
+	if code != 1 || !w.synced || w.Len() == 0 {
+		t.Errorf("code = %d, synced = %v, output %q", code, w.synced, w.String())
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -37,6 +38,11 @@ type RotatingWriterConfig struct {
 	MaxFiles  int    // Maximum number of old log files to retain
 	MaxAge    int    // Maximum number of days to retain old log files
 	Compress  bool   // Whether to compress old log files
+
+	// MaxTotalSize is the maximum combined size in megabytes of the current
+	// and backup log files in Directory, across all the loggers of a Factory.
+	// When exceeded, the oldest backups are deleted first. Zero means no limit.
+	MaxTotalSize int
 }
 
 // Config represents the logging configuration.
@@ -58,9 +64,12 @@ type Factory interface {
 	MakeChain(alias string) (Logger, error)
 
 	// MakeChainAndIndex creates loggers for a blockchain and its indexer.
+	// The index logger follows the level of the chain logger, and the named
+	// level rules matching it, unless its own level is set.
 	MakeChainAndIndex(alias string, index string) (Logger, Logger, error)
 
-	// SetLogLevel sets the log level for a named logger.
+	// SetLogLevel sets the log level for a named logger. It takes
+	// precedence over the rules set with SetNamedLevels.
 	SetLogLevel(name string, level Level)
 
 	// SetDisplayLevel sets the display level for a named logger.
@@ -78,18 +87,20 @@ type Factory interface {
 
 // factory implements Factory.
 type factory struct {
-	config  Config
-	loggers map[string]*factoryLogger
-	writers map[string]*lumberjack.Logger
-	mu      sync.RWMutex
-	closed  bool
+	config       Config
+	loggers      map[string]*factoryLogger
+	writers      map[string]*lumberjack.Logger
+	instrumented []*InstrumentedWriter
+	budget       *diskBudget
+	mu           sync.RWMutex
+	closed       bool
 }
 
 // factoryLogger wraps a Logger with factory-managed level controls.
 type factoryLogger struct {
-	Logger              // current logger with level applied
-	baseLogger   Logger // base logger without level (for SetLogLevel)
-	level        Level
+	Logger                 // logger reading its level from level
+	level        *LevelVar // shared with every logger derived from Logger
+	named        *loggerName
 	displayLevel Level
 	name         string
 	factory      *factory
@@ -105,15 +116,32 @@ func NewFactory() Factory {
 
 // NewFactoryWithConfig creates a new logger factory with the given configuration.
 func NewFactoryWithConfig(config Config) Factory {
-	return &factory{
+	f := &factory{
 		config:  config,
 		loggers: make(map[string]*factoryLogger),
 		writers: make(map[string]*lumberjack.Logger),
 	}
+	if config.Directory != "" && config.MaxTotalSize > 0 {
+		f.budget = newDiskBudget(config.Directory, config.MaxTotalSize, func(used, limit int64) {
+			Root().Warn("log directory is approaching its disk budget",
+				"directory", config.Directory,
+				"usedBytes", used,
+				"limitBytes", limit,
+			)
+		})
+	}
+	return f
 }
 
 // Make creates a new logger with the given name.
 func (f *factory) Make(name string) (Logger, error) {
+	return f.make(name, nil)
+}
+
+// make creates a new logger with the given name. If parent is not nil, the
+// logger follows its level, and the named level rules matching it, until its
+// own level is set.
+func (f *factory) make(name string, parent *factoryLogger) (Logger, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -121,7 +149,7 @@ func (f *factory) Make(name string) (Logger, error) {
 		return Noop(), nil
 	}
 
-	// Return existing logger if already created (reflects any SetLogLevel changes)
+	// Return existing logger if already created
 	if l, exists := f.loggers[name]; exists {
 		return l.Logger, nil
 	}
@@ -149,25 +177,26 @@ func (f *factory) Make(name string) (Logger, error) {
 			lj.MaxBackups = 5 // 5 files default
 		}
 		f.writers[name] = lj
-		writers = append(writers, lj)
+		var file io.Writer = lj
+		if f.budget != nil {
+			f.budget.track(lj.Filename)
+			file = f.budget.wrap(lj)
+		}
+		writers = append(writers, f.instrument(file, name, "file"))
 	}
 
 	// Add console writer if display is enabled
 	if !f.config.DisableWriterDisplaying {
+		var console io.Writer
 		switch f.config.LogFormat {
 		case JSON:
-			writers = append(writers, os.Stderr)
+			console = stdStream{os.Stderr}
 		case Colors, Auto:
-			writers = append(writers, NewConsoleWriter(func(w *ConsoleWriter) {
-				w.Out = os.Stderr
-				w.NoColor = false
-			}))
+			console = stdConsoleWriter(false)
 		default:
-			writers = append(writers, NewConsoleWriter(func(w *ConsoleWriter) {
-				w.Out = os.Stderr
-				w.NoColor = true
-			}))
+			console = stdConsoleWriter(true)
 		}
+		writers = append(writers, f.instrument(console, name, "console"))
 	}
 
 	// Create multi-writer
@@ -177,17 +206,28 @@ func (f *factory) Make(name string) (Logger, error) {
 	} else if len(writers) == 1 {
 		w = writers[0]
 	} else {
-		w = io.MultiWriter(writers...)
+		w = MultiLevelWriter(writers...)
 	}
 
-	// Create logger - store base logger separately so SetLogLevel can recreate with new level
-	baseLogger := NewWriter(w).With().Timestamp().Str("logger", name).Logger()
-	logger := baseLogger.Level(f.config.LogLevel)
+	// The level is shared with the returned logger and all its children, so
+	// SetLogLevel takes effect on loggers already handed out.
+	var level *LevelVar
+	if parent != nil {
+		level = parent.level.Child()
+	} else {
+		level = NewLevelVar(f.config.LogLevel)
+	}
+	baseLogger := NewWriter(w).With().Timestamp().Logger().(*logger)
+	baseLogger.level = level
+	namedLogger := baseLogger.Named(name).(*logger)
+	if parent != nil {
+		namedLogger.name.follow = parent.named
+	}
 
 	fl := &factoryLogger{
-		Logger:       logger,
-		baseLogger:   baseLogger,
-		level:        f.config.LogLevel,
+		Logger:       namedLogger,
+		level:        level,
+		named:        namedLogger.name,
 		displayLevel: f.config.DisplayLevel,
 		name:         name,
 		factory:      f,
@@ -197,35 +237,57 @@ func (f *factory) Make(name string) (Logger, error) {
 	return fl.Logger, nil
 }
 
+// stdConsoleWriter returns a ConsoleWriter writing to the standard error
+// stream, which closing it leaves open.
+func stdConsoleWriter(noColor bool) ConsoleWriter {
+	w := NewConsoleWriter(func(w *ConsoleWriter) {
+		w.Out = os.Stderr
+		w.NoColor = noColor
+	})
+	w.Out = stdStream{w.Out}
+	return w
+}
+
+// instrument wraps w in an InstrumentedWriter labelled with the logger name.
+// The caller must hold the lock.
+func (f *factory) instrument(w io.Writer, name, sink string) io.Writer {
+	iw := NewInstrumentedWriter(w, name, sink)
+	f.instrumented = append(f.instrumented, iw)
+	return iw
+}
+
 // MakeChain creates a logger for a blockchain.
 func (f *factory) MakeChain(alias string) (Logger, error) {
 	return f.Make("chain." + alias)
 }
 
-// MakeChainAndIndex creates loggers for a blockchain and its indexer.
+// MakeChainAndIndex creates loggers for a blockchain and its indexer. The
+// index logger follows the level of the chain logger, and the named level
+// rules matching it, unless its own level is set with SetLogLevel.
 func (f *factory) MakeChainAndIndex(alias string, index string) (Logger, Logger, error) {
 	chainLogger, err := f.MakeChain(alias)
 	if err != nil {
 		return Noop(), Noop(), err
 	}
-	indexLogger, err := f.Make("index." + alias + "." + index)
+	f.mu.RLock()
+	chain := f.loggers["chain."+alias]
+	f.mu.RUnlock()
+	indexLogger, err := f.make("index."+alias+"."+index, chain)
 	if err != nil {
 		return Noop(), Noop(), err
 	}
 	return chainLogger, indexLogger, nil
 }
 
-// SetLogLevel sets the log level for a named logger.
+// SetLogLevel sets the log level for a named logger. It takes precedence over
+// the rules set with SetNamedLevels matching the logger.
 func (f *factory) SetLogLevel(name string, level Level) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	if l, exists := f.loggers[name]; exists {
-		l.level = level
-		// Recreate logger with new level using the base logger
-		if l.baseLogger != nil {
-			l.Logger = l.baseLogger.Level(level)
-		}
+		l.named.explicit.Store(true)
+		l.level.Set(level)
 	}
 }
 
@@ -245,7 +307,7 @@ func (f *factory) GetLogLevel(name string) (Level, error) {
 	defer f.mu.RUnlock()
 
 	if l, exists := f.loggers[name]; exists {
-		return l.level, nil
+		return l.level.Level(), nil
 	}
 	return InfoLevel, nil
 }
@@ -270,6 +332,10 @@ func (f *factory) Close() {
 	for _, w := range f.writers {
 		_ = w.Close()
 	}
+	for _, w := range f.instrumented {
+		w.Unregister()
+	}
+	f.instrumented = nil
 }
 
 // NoLog is a no-op logger for use in tests or when logging is disabled.
@@ -280,6 +346,8 @@ func (NoLog) With() Context                     { return Context{} }
 func (NoLog) Level(Level) Logger                { return Noop() }
 func (NoLog) GetLevel() Level                   { return Disabled }
 func (NoLog) New(...interface{}) Logger         { return Noop() }
+func (NoLog) Named(string) Logger               { return Noop() }
+func (NoLog) Ctx(context.Context) Logger        { return Noop() }
 func (NoLog) Sample(Sampler) Logger             { return Noop() }
 func (NoLog) Hook(...Hook) Logger               { return Noop() }
 func (NoLog) Trace(string, ...interface{})      {}
@@ -317,6 +385,22 @@ func (NoLog) RecoverAndPanic(fn func()) {
 func (NoLog) IsZero() bool                             { return true }
 func (NoLog) Enabled(context.Context, slog.Level) bool { return false }
 
+func (n NoLog) Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return startSpan(n, ctx, name, kv)
+}
+
+func (n NoLog) Every(interval time.Duration) Throttle {
+	return Throttle{l: n, every: interval}
+}
+
+func (n NoLog) FirstN(count int) Throttle {
+	return Throttle{l: n, firstN: true, n: count}
+}
+
+func (n NoLog) Once(key string) Once {
+	return Once{l: n, key: key}
+}
+
 // ToLevel parses a level string and returns the corresponding Level.
 func ToLevel(s string) (Level, error) {
 	switch strings.ToLower(s) {
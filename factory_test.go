@@ -0,0 +1,112 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"testing"
+)
+
+func TestFactorySetLogLevelIsLive(t *testing.T) {
+	f := NewFactoryWithConfig(Config{
+		RotatingWriterConfig:    RotatingWriterConfig{Directory: t.TempDir()},
+		LogLevel:                InfoLevel,
+		DisableWriterDisplaying: true,
+	})
+	defer f.Close()
+
+	l, err := f.Make("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := l.New("component", "child")
+	if child.DebugEvent().Enabled() {
+		t.Fatal("debug enabled before SetLogLevel")
+	}
+
+	var changes []Level
+	LevelVarOf(l).OnChange(func(old, new Level) { changes = append(changes, new) })
+
+	f.SetLogLevel("test", DebugLevel)
+	for _, lg := range []Logger{l, child, child.Hook(), child.Sample(&BasicSampler{N: 1})} {
+		if lg.GetLevel() != DebugLevel {
+			t.Errorf("GetLevel() = %v, want %v", lg.GetLevel(), DebugLevel)
+		}
+	}
+	if len(changes) != 1 || changes[0] != DebugLevel {
+		t.Errorf("changes = %v, want [debug]", changes)
+	}
+	if lvl, _ := f.GetLogLevel("test"); lvl != DebugLevel {
+		t.Errorf("GetLogLevel() = %v, want %v", lvl, DebugLevel)
+	}
+
+	detached := l.Level(ErrorLevel)
+	f.SetLogLevel("test", WarnLevel)
+	if detached.GetLevel() != ErrorLevel {
+		t.Errorf("Level() child followed the shared level: got %v", detached.GetLevel())
+	}
+}
+
+func TestFactoryIndexFollowsChainLevel(t *testing.T) {
+	f := NewFactoryWithConfig(Config{
+		RotatingWriterConfig:    RotatingWriterConfig{Directory: t.TempDir()},
+		LogLevel:                InfoLevel,
+		DisableWriterDisplaying: true,
+	})
+	defer f.Close()
+
+	chain, index, err := f.MakeChainAndIndex("C", "tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.SetLogLevel("chain.C", DebugLevel)
+	if index.GetLevel() != DebugLevel {
+		t.Errorf("index level = %v, want it to follow the chain level %v", index.GetLevel(), DebugLevel)
+	}
+
+	f.SetLogLevel("index.C.tx", ErrorLevel)
+	f.SetLogLevel("chain.C", WarnLevel)
+	if index.GetLevel() != ErrorLevel || chain.GetLevel() != WarnLevel {
+		t.Errorf("levels = %v, %v, want %v, %v", chain.GetLevel(), index.GetLevel(), WarnLevel, ErrorLevel)
+	}
+}
+
+func TestFactoryNamedLevels(t *testing.T) {
+	defer SetNamedLevels("")
+
+	f := NewFactoryWithConfig(Config{
+		RotatingWriterConfig:    RotatingWriterConfig{Directory: t.TempDir()},
+		LogLevel:                InfoLevel,
+		DisableWriterDisplaying: true,
+	})
+	defer f.Close()
+
+	chain, index, err := f.MakeChainAndIndex("C", "tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetNamedLevels("chain.C=debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !chain.WithLevel(DebugLevel).Enabled() || !index.WithLevel(DebugLevel).Enabled() {
+		t.Error("the chain and index loggers did not follow the chain rule")
+	}
+
+	// An explicit level wins over the rules.
+	f.SetLogLevel("chain.C", WarnLevel)
+	if chain.WithLevel(InfoLevel).Enabled() || index.WithLevel(InfoLevel).Enabled() {
+		t.Error("SetLogLevel did not take precedence over the chain rule")
+	}
+
+	if err := SetNamedLevels("index.C.tx=debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !index.WithLevel(DebugLevel).Enabled() {
+		t.Error("the index logger ignored its own rule")
+	}
+	f.SetLogLevel("index.C.tx", ErrorLevel)
+	if index.WithLevel(WarnLevel).Enabled() {
+		t.Error("SetLogLevel did not take precedence over the index rule")
+	}
+}
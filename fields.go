@@ -53,7 +53,7 @@ func appendFieldList(dst []byte, kvList []interface{}, stack bool, ctx context.C
 		} else {
 			continue
 		}
-		switch val := val.(type) {
+		switch val := resolveLazy(val).(type) {
 		case string:
 			dst = enc.AppendString(dst, val)
 		case []byte:
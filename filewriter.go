@@ -0,0 +1,271 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy defines when a FileWriter flushes its file to stable storage.
+type FsyncPolicy int
+
+const (
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever FsyncPolicy = iota
+	// FsyncEveryN syncs after every FileWriterConfig.FsyncEvery lines.
+	FsyncEveryN
+	// FsyncInterval syncs every FileWriterConfig.FsyncInterval if anything
+	// was written since the last sync.
+	FsyncInterval
+	// FsyncOnLevel only syncs immediately after a line at
+	// FileWriterConfig.FsyncLevel or above, such as an Error or Fatal line,
+	// is written. The other policies, except FsyncNever, do so too.
+	FsyncOnLevel
+)
+
+// FileWriterConfig configures a FileWriter.
+type FileWriterConfig struct {
+	// Path of the log file. Lines are appended to it.
+	Path string
+
+	// Fsync is the fsync policy.
+	Fsync FsyncPolicy
+
+	// FsyncEvery is the number of lines between two syncs with FsyncEveryN.
+	FsyncEvery int
+
+	// FsyncInterval is the time between two syncs with FsyncInterval.
+	FsyncInterval time.Duration
+
+	// FsyncLevel is the lowest level synced immediately by every policy but
+	// FsyncNever, ErrorLevel if nil. Set it to TraceLevel to sync every
+	// leveled line, or to Disabled to only follow the policy.
+	FsyncLevel *Level
+
+	// SlowFsyncThreshold, if set, reports through ErrorHandler any fsync
+	// taking longer than this duration.
+	SlowFsyncThreshold time.Duration
+}
+
+// FileWriter is a LevelWriter appending log lines to a file with a
+// configurable fsync policy, for audit-grade and post-mortem logs.
+//
+// On open, a final line left truncated by a power loss (including the zero
+// bytes some file systems leave behind) is cut off so that the file only
+// contains complete lines.
+type FileWriter struct {
+	config     FileWriterConfig
+	fsyncLevel Level
+
+	mu       sync.Mutex
+	f        *os.File
+	unsynced int // lines written since the last sync
+	closed   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenFileWriter opens, or creates, the file at config.Path.
+func OpenFileWriter(config FileWriterConfig) (*FileWriter, error) {
+	switch config.Fsync {
+	case FsyncEveryN:
+		if config.FsyncEvery <= 0 {
+			return nil, fmt.Errorf("file writer: FsyncEvery must be positive, got %d", config.FsyncEvery)
+		}
+	case FsyncInterval:
+		if config.FsyncInterval <= 0 {
+			return nil, fmt.Errorf("file writer: FsyncInterval must be positive, got %s", config.FsyncInterval)
+		}
+	}
+
+	if dir := filepath.Dir(config.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(config.Path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := recoverTruncatedLine(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	w := &FileWriter{
+		config:     config,
+		fsyncLevel: ErrorLevel,
+		f:          f,
+	}
+	if config.FsyncLevel != nil {
+		w.fsyncLevel = *config.FsyncLevel
+	}
+	if config.Fsync == FsyncInterval {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// recoverTruncatedLine cuts the file after its last newline, discarding an
+// incomplete final line and any trailing zero bytes.
+func recoverTruncatedLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+
+	// Search backwards for the last newline.
+	const chunk = 4096
+	buf := make([]byte, chunk)
+	end := size
+	for end > 0 {
+		start := max(end-chunk, 0)
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == size {
+		return nil
+	}
+
+	if err := f.Truncate(end); err != nil {
+		return err
+	}
+	reportWriterError(fmt.Errorf("file writer: discarded %d bytes of truncated final line in %s", size-end, f.Name()))
+	return f.Sync()
+}
+
+// Write implements the io.Writer interface.
+func (w *FileWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *FileWriter) WriteLevel(l Level, p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	n, err = w.f.Write(p)
+	if err != nil {
+		return n, err
+	}
+	w.unsynced++
+
+	if w.config.Fsync == FsyncNever {
+		return n, nil
+	}
+	if (w.config.Fsync == FsyncEveryN && w.unsynced >= w.config.FsyncEvery) ||
+		(l >= w.fsyncLevel && l != NoLevel && l != Disabled) {
+		err = w.sync()
+	}
+	return n, err
+}
+
+// Sync flushes the file to stable storage.
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.sync()
+}
+
+// sync expects lock to be held.
+func (w *FileWriter) sync() error {
+	start := time.Now()
+	err := w.f.Sync()
+	elapsed := time.Since(start)
+	if err == nil {
+		w.unsynced = 0
+	}
+	if threshold := w.config.SlowFsyncThreshold; threshold > 0 && elapsed > threshold {
+		reportWriterError(fmt.Errorf("file writer: fsync of %s took %s", w.f.Name(), elapsed))
+	}
+	return err
+}
+
+func (w *FileWriter) syncLoop() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if !w.closed && w.unsynced > 0 {
+				if err := w.sync(); err != nil {
+					reportWriterError(fmt.Errorf("file writer: fsync of %s failed: %w", w.f.Name(), err))
+				}
+			}
+			w.mu.Unlock()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Close syncs and closes the file. Policies other than FsyncNever sync any
+// remaining lines first.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.config.Fsync != FsyncNever && w.unsynced > 0 {
+		err = w.sync()
+	}
+	if closeErr := w.f.Close(); err == nil {
+		err = closeErr
+	}
+	w.mu.Unlock()
+
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	return err
+}
+
+// reportWriterError reports an error that can not be returned to the caller
+// through ErrorHandler, or on stderr if it is not set.
+func reportWriterError(err error) {
+	if ErrorHandler != nil {
+		ErrorHandler(err)
+	} else {
+		fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+	}
+}
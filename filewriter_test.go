@@ -0,0 +1,141 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecoverTruncatedLine(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"empty", "", ""},
+		{"complete", "a\nb\n", "a\nb\n"},
+		{"partial line", "a\nb\npart", "a\nb\n"},
+		{"zero bytes", "a\n\x00\x00\x00", "a\n"},
+		{"no newline", "partial", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			saved := ErrorHandler
+			ErrorHandler = func(error) {}
+			defer func() { ErrorHandler = saved }()
+
+			w, err := OpenFileWriter(FileWriterConfig{Path: path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("next\n"))
+			w.Close()
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want+"next\n" {
+				t.Errorf("file = %q, want %q", got, tt.want+"next\n")
+			}
+		})
+	}
+}
+
+func TestFileWriterFsyncPolicies(t *testing.T) {
+	type write struct {
+		level        Level
+		wantUnsynced int
+	}
+	tests := []struct {
+		name   string
+		config FileWriterConfig
+		writes []write
+	}{
+		{
+			name:   "never",
+			config: FileWriterConfig{Fsync: FsyncNever},
+			writes: []write{{InfoLevel, 1}, {ErrorLevel, 2}},
+		},
+		{
+			name:   "every n",
+			config: FileWriterConfig{Fsync: FsyncEveryN, FsyncEvery: 2},
+			writes: []write{{DebugLevel, 1}, {DebugLevel, 0}, {ErrorLevel, 0}},
+		},
+		{
+			name:   "on level defaults to error",
+			config: FileWriterConfig{Fsync: FsyncOnLevel},
+			writes: []write{{DebugLevel, 1}, {WarnLevel, 2}, {NoLevel, 3}, {ErrorLevel, 0}},
+		},
+		{
+			name:   "on level trace",
+			config: FileWriterConfig{Fsync: FsyncOnLevel, FsyncLevel: new(TraceLevel)},
+			writes: []write{{TraceLevel, 0}, {NoLevel, 1}},
+		},
+		{
+			name:   "on level debug",
+			config: FileWriterConfig{Fsync: FsyncOnLevel, FsyncLevel: new(DebugLevel)},
+			writes: []write{{TraceLevel, 1}, {DebugLevel, 0}},
+		},
+		{
+			name:   "interval with sync on error",
+			config: FileWriterConfig{Fsync: FsyncInterval, FsyncInterval: time.Hour},
+			writes: []write{{InfoLevel, 1}, {FatalLevel, 0}},
+		},
+		{
+			name:   "every n without sync on level",
+			config: FileWriterConfig{Fsync: FsyncEveryN, FsyncEvery: 3, FsyncLevel: new(Disabled)},
+			writes: []write{{ErrorLevel, 1}, {PanicLevel, 2}, {InfoLevel, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Path = filepath.Join(t.TempDir(), "audit.log")
+			w, err := OpenFileWriter(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+			for i, wr := range tt.writes {
+				if _, err := w.WriteLevel(wr.level, []byte("line\n")); err != nil {
+					t.Fatal(err)
+				}
+				w.mu.Lock()
+				unsynced := w.unsynced
+				w.mu.Unlock()
+				if unsynced != wr.wantUnsynced {
+					t.Errorf("write %d at %v: %d unsynced lines, want %d", i, wr.level, unsynced, wr.wantUnsynced)
+				}
+			}
+		})
+	}
+}
+
+func TestFileWriterFsyncInterval(t *testing.T) {
+	w, err := OpenFileWriter(FileWriterConfig{
+		Path:          filepath.Join(t.TempDir(), "audit.log"),
+		Fsync:         FsyncInterval,
+		FsyncInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.WriteLevel(InfoLevel, []byte("line\n"))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		unsynced := w.unsynced
+		w.mu.Unlock()
+		if unsynced == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("line not synced by the interval loop")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
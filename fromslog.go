@@ -0,0 +1,267 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+)
+
+// slogWriter is a LevelWriter turning the events of a Logger into records of
+// a slog.Handler.
+type slogWriter struct {
+	h slog.Handler
+
+	mu sync.Mutex
+	// contexts caches the attributes decoded from logger contexts.
+	contexts map[string][]slog.Attr
+}
+
+// slogContextCacheSize bounds the number of logger contexts whose attributes
+// a slogWriter caches.
+const slogContextCacheSize = 256
+
+// slogMark is the typed value of the field encoded in the buffer of an event
+// from start to end, or marks the logger context if context is set.
+type slogMark struct {
+	start, end int
+	attr       slog.Attr
+	context    bool
+}
+
+// levelCtxWriter is a LevelWriter filtering events by level itself and taking
+// their Go context and typed fields, such as slogWriter. Loggers writing to
+// one check its level before building events, and events pass it their
+// context and marks.
+type levelCtxWriter interface {
+	LevelWriter
+	enabledLevel(lvl Level) bool
+	writeLevelCtx(ctx context.Context, level Level, p []byte, marks []slogMark) (int, error)
+}
+
+// FromSlogHandler returns a Logger backed by h, so that embedders who already
+// have a slog.Handler (an OpenTelemetry bridge, a test handler, GlogHandler)
+// can pass it wherever a Logger is expected. Every Logger method is supported:
+// each event, including the fields of its logger context, is emitted as a
+// single slog.Record, with the fields as attributes, nested objects as groups,
+// the message and level of the event, and the PC of the code that logged it.
+// The Go context of the event is passed to h.Handle.
+//
+// Events below the level enabled by h are dropped before they are built. The
+// fields added with the scalar methods of Event, such as Str, Int, Uint64,
+// Time, Dur and Err, with Dict, or as key-value pairs, keep their type: times,
+// durations and errors are passed as such. The other fields, such as arrays,
+// objects and raw JSON, are decoded from their JSON encoding, which allocates,
+// and the values encoded as strings are passed as strings. The fields of each
+// logger context are decoded once and cached.
+func FromSlogHandler(h slog.Handler) Logger {
+	if sh, ok := h.(*slogHandler); ok && len(sh.goas) == 0 {
+		return sh.l
+	}
+	return &logger{w: &slogWriter{h: h}, level: NewLevelVar(TraceLevel)}
+}
+
+// enabledLevel reports whether the handler logs events at lvl.
+func (w *slogWriter) enabledLevel(lvl Level) bool {
+	return w.h.Enabled(context.Background(), levelToSlog(lvl))
+}
+
+func (w *slogWriter) Write(p []byte) (int, error) {
+	return w.writeLevelCtx(context.Background(), NoLevel, p, nil)
+}
+
+func (w *slogWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return w.writeLevelCtx(context.Background(), level, p, nil)
+}
+
+// writeLevelCtx passes the event encoded in p, whose typed fields are marks,
+// to the handler. Lines that are not JSON objects are logged as the message.
+func (w *slogWriter) writeLevelCtx(ctx context.Context, level Level, p []byte, marks []slogMark) (int, error) {
+	var pc uintptr
+	if s := callerSite(); s != nil {
+		pc = s.pc
+	}
+	r := slog.NewRecord(TimestampFunc(), levelToSlog(level), "", pc)
+	var buf [16]slog.Attr
+	attrs, err := w.attrs(buf[:0], p, marks)
+	if err != nil {
+		r.Message = string(bytes.TrimRight(p, "\n"))
+	} else {
+		addSlogAttrs(&r, attrs)
+	}
+	return len(p), w.h.Handle(ctx, r)
+}
+
+// addSlogAttrs adds attrs to r. The message field becomes the record message,
+// while the level, timestamp and caller fields are dropped in favor of the
+// record's own.
+func addSlogAttrs(r *slog.Record, attrs []slog.Attr) {
+	n := 0
+	for _, a := range attrs {
+		switch a.Key {
+		case MessageFieldName:
+			if a.Value.Kind() == slog.KindString {
+				r.Message = a.Value.String()
+				continue
+			}
+		case LevelFieldName, TimestampFieldName, CallerFieldName:
+			continue
+		}
+		attrs[n] = a
+		n++
+	}
+	r.AddAttrs(attrs[:n]...)
+}
+
+// slogAttrs appends to dst the attributes of the JSON object p, whose typed
+// fields are marks.
+func slogAttrs(dst []slog.Attr, p []byte, marks []slogMark) ([]slog.Attr, error) {
+	return (*slogWriter)(nil).attrs(dst, p, marks)
+}
+
+// attrs is slogAttrs, caching the attributes of the logger contexts in w if
+// w is not nil.
+func (w *slogWriter) attrs(dst []slog.Attr, p []byte, marks []slogMark) ([]slog.Attr, error) {
+	if n := len(p); n > 0 && p[n-1] == '\n' {
+		p = p[:n-1]
+	}
+	if len(p) < 2 || p[0] != '{' || p[len(p)-1] != '}' {
+		return dst, errNotJSONObject
+	}
+	var err error
+	pos := 1
+	for _, m := range marks {
+		if m.start > pos {
+			if dst, err = decodeSlogFields(dst, p[pos:m.start]); err != nil {
+				return dst, err
+			}
+		}
+		switch {
+		case !m.context:
+			dst = append(dst, m.attr)
+		case w != nil:
+			dst, err = w.contextAttrs(dst, p[m.start:m.end])
+		default:
+			dst, err = decodeSlogFields(dst, p[m.start:m.end])
+		}
+		if err != nil {
+			return dst, err
+		}
+		pos = m.end
+	}
+	if end := len(p) - 1; end > pos {
+		return decodeSlogFields(dst, p[pos:end])
+	}
+	return dst, nil
+}
+
+// contextAttrs appends to dst the attributes of the logger context encoded in
+// p, decoded once.
+func (w *slogWriter) contextAttrs(dst []slog.Attr, p []byte) ([]slog.Attr, error) {
+	w.mu.Lock()
+	attrs, ok := w.contexts[string(p)]
+	w.mu.Unlock()
+	if !ok {
+		var err error
+		if attrs, err = decodeSlogFields(nil, p); err != nil {
+			return dst, err
+		}
+		w.mu.Lock()
+		if w.contexts == nil || len(w.contexts) >= slogContextCacheSize {
+			w.contexts = make(map[string][]slog.Attr)
+		}
+		w.contexts[string(p)] = attrs
+		w.mu.Unlock()
+	}
+	return append(dst, attrs...), nil
+}
+
+// decodeSlogFields appends to dst the attributes decoded from p, the fields of
+// a JSON object without its braces.
+func decodeSlogFields(dst []slog.Attr, p []byte) ([]slog.Attr, error) {
+	p = bytes.TrimPrefix(p, []byte{','})
+	if len(p) == 0 {
+		return dst, nil
+	}
+	obj := make([]byte, 0, len(p)+2)
+	obj = append(append(append(obj, '{'), p...), '}')
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return dst, errNotJSONObject
+	}
+	for dec.More() {
+		key, v, err := decodeSlogAttr(dec)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, slog.Attr{Key: key, Value: v})
+	}
+	return dst, nil
+}
+
+var errNotJSONObject = errors.New("not a JSON object")
+
+func decodeSlogAttr(dec *json.Decoder) (string, slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", slog.Value{}, err
+	}
+	key, _ := tok.(string)
+	v, err := decodeSlogValue(dec)
+	return key, v, err
+}
+
+// decodeSlogValue decodes the next JSON value, turning objects into groups.
+func decodeSlogValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			var attrs []slog.Attr
+			for dec.More() {
+				key, v, err := decodeSlogAttr(dec)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				attrs = append(attrs, slog.Attr{Key: key, Value: v})
+			}
+			_, err := dec.Token()
+			return slog.GroupValue(attrs...), err
+		}
+		vals := []any{}
+		for dec.More() {
+			v, err := decodeSlogValue(dec)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			vals = append(vals, v.Any())
+		}
+		_, err := dec.Token()
+		return slog.AnyValue(vals), err
+	case string:
+		return slog.StringValue(t), nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return slog.Uint64Value(u), nil
+		}
+		f, err := t.Float64()
+		return slog.Float64Value(f), err
+	case bool:
+		return slog.BoolValue(t), nil
+	default:
+		return slog.AnyValue(nil), nil
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFromSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := FromSlogHandler(h).With().Str("component", "test").Logger()
+
+	l.Debug("dropped")
+	l.Info("msg", "n", 1, "ok", true)
+	l.WarnEvent().Dict("d", Dict().Float64("f", 1.5)).Strs("s", []string{"a"}).Msg("event")
+	l.WithLevel(FatalLevel).Msg("fatal")
+
+	want := "level=INFO msg=msg component=test n=1 ok=true\n" +
+		"level=WARN msg=event component=test d.f=1.5 s=[a]\n" +
+		"level=ERROR+4 msg=fatal component=test\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// recordHandler is a slog.Handler keeping the records it handles.
+type recordHandler struct {
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func TestFromSlogHandlerTypes(t *testing.T) {
+	h := &recordHandler{}
+	l := FromSlogHandler(h).With().Int("ctx", 1).Logger()
+
+	l.InfoEvent().
+		Str("s", "x").
+		Int("i", -2).
+		Uint64("u", math.MaxUint64).
+		Float64("f", 1.5).
+		Bool("b", true).
+		Dict("d", Dict().Int("n", 3)).
+		Ints("a", []int{1, 2}).
+		Msg("msg")
+
+	if len(h.records) != 1 {
+		t.Fatalf("got %d records, want 1", len(h.records))
+	}
+	r := h.records[0]
+	if r.Message != "msg" || r.Level != slog.LevelInfo {
+		t.Errorf("got message %q at %v", r.Message, r.Level)
+	}
+	want := map[string]slog.Kind{
+		"ctx": slog.KindInt64,
+		"s":   slog.KindString,
+		"i":   slog.KindInt64,
+		"u":   slog.KindUint64,
+		"f":   slog.KindFloat64,
+		"b":   slog.KindBool,
+		"d":   slog.KindGroup,
+		"a":   slog.KindAny,
+	}
+	got := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		got[a.Key] = a.Value
+		return true
+	})
+	for key, kind := range want {
+		if v, ok := got[key]; !ok || v.Kind() != kind {
+			t.Errorf("%s: got %v (%v), want kind %v", key, v, v.Kind(), kind)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got attributes %v", got)
+	}
+	if v := got["u"]; v.Kind() == slog.KindUint64 && v.Uint64() != math.MaxUint64 {
+		t.Errorf("u = %v, want %v", v.Uint64(), uint64(math.MaxUint64))
+	}
+	if g := got["d"]; g.Kind() == slog.KindGroup {
+		if attrs := g.Group(); len(attrs) != 1 || attrs[0].Key != "n" || attrs[0].Value.Int64() != 3 {
+			t.Errorf("d = %v", attrs)
+		}
+	}
+}
+
+func TestFromSlogHandlerTypedValues(t *testing.T) {
+	h := &recordHandler{}
+	l := FromSlogHandler(h).With().Str("ctx", "c").Logger()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	errBad := errors.New("bad")
+	e := l.InfoEvent()
+	e.Time("t", now).
+		Dur("d", time.Second).
+		Err(errBad).
+		Dict("g", e.CreateDict().Time("nt", now).Ints("a", []int{1})).
+		RawJSON("raw", []byte(`{"x":1}`)).
+		Msg("msg")
+	l.Info("kv", "t", now, "d", time.Second)
+
+	if len(h.records) != 2 {
+		t.Fatalf("got %d records, want 2", len(h.records))
+	}
+	got := make(map[string]slog.Value)
+	h.records[0].Attrs(func(a slog.Attr) bool {
+		got[a.Key] = a.Value
+		return true
+	})
+	if v := got["t"]; v.Kind() != slog.KindTime || !v.Time().Equal(now) {
+		t.Errorf("t = %v (%v), want a time", v, v.Kind())
+	}
+	if v := got["d"]; v.Kind() != slog.KindDuration || v.Duration() != time.Second {
+		t.Errorf("d = %v (%v), want a duration", v, v.Kind())
+	}
+	if v := got[ErrorFieldName]; v.Kind() != slog.KindAny || v.Any() != errBad {
+		t.Errorf("error = %v (%v), want the error", v, v.Kind())
+	}
+	if v := got["ctx"]; v.Kind() != slog.KindString || v.String() != "c" {
+		t.Errorf("ctx = %v (%v), want the context field", v, v.Kind())
+	}
+	if v := got["raw"]; v.Kind() != slog.KindGroup {
+		t.Errorf("raw = %v (%v), want a group", v, v.Kind())
+	}
+	if v := got["g"]; v.Kind() != slog.KindGroup {
+		t.Errorf("g = %v (%v), want a group", v, v.Kind())
+	} else if attrs := v.Group(); len(attrs) != 2 || attrs[0].Value.Kind() != slog.KindTime ||
+		attrs[1].Value.Kind() != slog.KindAny {
+		t.Errorf("g = %v", attrs)
+	}
+
+	h.records[1].Attrs(func(a slog.Attr) bool {
+		got[a.Key] = a.Value
+		return true
+	})
+	if got["t"].Kind() != slog.KindTime || got["d"].Kind() != slog.KindDuration {
+		t.Errorf("key-value pairs lost their types: %v", got)
+	}
+}
+
+// nopHandler is a slog.Handler discarding the records.
+type nopHandler struct{}
+
+func (nopHandler) Enabled(context.Context, slog.Level) bool  { return true }
+func (nopHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h nopHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h nopHandler) WithGroup(string) slog.Handler           { return h }
+
+func TestFromSlogHandlerAllocs(t *testing.T) {
+	l := FromSlogHandler(nopHandler{}).With().Str("ctx", "c").Logger()
+	now := time.Now()
+	allocs := testing.AllocsPerRun(100, func() {
+		l.InfoEvent().Str("s", "x").Int("i", 1).Time("t", now).Dur("d", time.Second).Msg("msg")
+	})
+	if allocs != 0 {
+		t.Errorf("typed events allocate %v times", allocs)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -77,21 +78,40 @@ func Stack(key string) Field {
 // explicitly enabled via SetDefault() or SetGlobalLevel(DebugLevel).
 // This prevents dependency init() functions from spamming stderr
 // with debug output before the application configures logging.
-var defaultLogger = NewWriter(os.Stderr).With().Timestamp().Logger().Level(InfoLevel)
+var defaultLogger atomic.Pointer[Logger]
 
-// SetDefault sets the default logger for geth-style functions
+func init() {
+	l := NewWriter(os.Stderr).With().Timestamp().Logger().Level(InfoLevel)
+	defaultLogger.Store(&l)
+}
+
+// rootLogger returns the logger set with SetDefault.
+func rootLogger() Logger {
+	return *defaultLogger.Load()
+}
+
+// SetDefault sets the default logger for geth-style functions. Loggers
+// obtained from Root or Default, and their children created with New and
+// Named, follow the new logger from then on.
 func SetDefault(l Logger) {
-	defaultLogger = l
+	l = resolveRoot(l)
+	if l == nil {
+		l = Noop()
+	}
+	defaultLogger.Store(&l)
 }
 
-// Root returns the default logger
+// Root returns the default logger. It resolves the logger set with SetDefault
+// at each call, so packages can obtain it, or a child created with New or
+// Named, at init time and still log through the logger the application
+// configures later.
 func Root() Logger {
-	return defaultLogger
+	return rootProxySingleton
 }
 
 // Default returns the default logger (alias for Root)
 func Default() Logger {
-	return defaultLogger
+	return rootProxySingleton
 }
 
 // UserString returns a Field for user-provided string values.
@@ -103,7 +123,10 @@ func Reflect(key string, val interface{}) Field { return Any(key, val) }
 
 // applyField applies a single Field to an Event.
 func applyField(e *Event, f Field) *Event {
-	switch v := f.Value.(type) {
+	if e == nil {
+		return nil
+	}
+	switch v := resolveLazy(f.Value).(type) {
 	case string:
 		return e.Str(f.Key, v)
 	case int:
@@ -135,7 +158,7 @@ func applyField(e *Event, f Field) *Event {
 		}
 		return e
 	default:
-		return e.Interface(f.Key, f.Value)
+		return e.Interface(f.Key, v)
 	}
 }
 
@@ -163,7 +186,7 @@ func applyContext(e *Event, ctx []interface{}) *Event {
 			i++
 			continue
 		}
-		val := ctx[i+1]
+		val := resolveLazy(ctx[i+1])
 		i += 2
 		switch v := val.(type) {
 		case string:
@@ -223,32 +246,32 @@ func applyContext(e *Event, ctx []interface{}) *Event {
 
 // Trace logs at trace level with geth-style context
 func Trace(msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.TraceEvent(), ctx).Msg(msg)
+	applyContext(rootLogger().TraceEvent(), ctx).Msg(msg)
 }
 
 // Debug logs at debug level with geth-style context
 func Debug(msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.DebugEvent(), ctx).Msg(msg)
+	applyContext(rootLogger().DebugEvent(), ctx).Msg(msg)
 }
 
 // Info logs at info level with geth-style context
 func Info(msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.InfoEvent(), ctx).Msg(msg)
+	applyContext(rootLogger().InfoEvent(), ctx).Msg(msg)
 }
 
 // Warn logs at warn level with geth-style context
 func Warn(msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.WarnEvent(), ctx).Msg(msg)
+	applyContext(rootLogger().WarnEvent(), ctx).Msg(msg)
 }
 
 // Error logs at error level with geth-style context
 func Error(msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.ErrorEvent(), ctx).Msg(msg)
+	applyContext(rootLogger().ErrorEvent(), ctx).Msg(msg)
 }
 
 // Fatal logs at fatal level with geth-style context and exits
 func Fatal(msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.FatalEvent(), ctx).Msg(msg)
+	applyContext(rootLogger().FatalEvent(), ctx).Msg(msg)
 }
 
 // Crit is an alias for Fatal (geth compatibility)
@@ -258,7 +281,7 @@ func Crit(msg string, ctx ...interface{}) {
 
 // Log logs at the specified level with geth-style context
 func Log(level Level, msg string, ctx ...interface{}) {
-	applyContext(defaultLogger.WithLevel(level), ctx).Msg(msg)
+	applyContext(rootLogger().WithLevel(level), ctx).Msg(msg)
 }
 
 // NewNoOpLogger returns a disabled logger.
@@ -3,6 +3,7 @@ package log
 import (
 	"bytes"
 	"encoding/json"
+	"os"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -63,6 +64,40 @@ var (
 		return l.String()
 	}
 
+	// LoggerFieldName is the field name used for the name of named loggers.
+	LoggerFieldName = "logger"
+
+	// TraceIDFieldName is the field name used for W3C trace IDs, see
+	// TraceContextExtractor.
+	TraceIDFieldName = "trace_id"
+
+	// SpanIDFieldName is the field name used for W3C span IDs, see
+	// TraceContextExtractor.
+	SpanIDFieldName = "span_id"
+
+	// SpanFieldName is the field name used for the IDs of the spans started
+	// with Logger.Start, which are not W3C span IDs.
+	SpanFieldName = "span"
+
+	// ParentSpanFieldName is the field name used for the ID of the enclosing
+	// span of a span started with Logger.Start.
+	ParentSpanFieldName = "parent_span"
+
+	// ElapsedFieldName is the field name used for the time elapsed by spans
+	// and aggregation windows.
+	ElapsedFieldName = "elapsed"
+
+	// OutcomeFieldName is the field name used for the outcome of spans.
+	OutcomeFieldName = "outcome"
+
+	// SuppressedFieldName is the field name used for the number of events a
+	// Throttle suppressed before the one it is added to.
+	SuppressedFieldName = "suppressed"
+
+	// ForcedFieldName is the field name marking the events logged because
+	// their context forced their level, see WithForcedLevel.
+	ForcedFieldName = "forced"
+
 	// MessageFieldName is the field name used for the message field.
 	MessageFieldName = "message"
 
@@ -134,6 +169,20 @@ var (
 	// be thread safe and non-blocking.
 	ErrorHandler func(err error)
 
+	// ExitFunc is called with exit code 1 to end the process after a Fatal
+	// event is written and the OnFatal hooks have run, unless the logger has
+	// its own exit function (see Context.ExitFunc).
+	ExitFunc = os.Exit
+
+	// FatalHookTimeout bounds the time the OnFatal hooks may take before the
+	// process exits anyway.
+	FatalHookTimeout = 5 * time.Second
+
+	// SlowSpanThreshold is the duration above which the spans started with
+	// Logger.Start are logged as slow when they end, see Span.SlowAfter.
+	// Spans are never slow if it is 0.
+	SlowSpanThreshold time.Duration
+
 	// DefaultContextLogger is returned from Ctx() if there is no logger associated
 	// with the context.
 	DefaultContextLogger Logger
@@ -166,6 +215,10 @@ var (
 	// from the TriggerLevelWriter buffer pool if the buffer grows above the limit.
 	TriggerLevelWriterBufferReuseLimit = 64 * 1024
 
+	// TriggerScopeMaxBufferSize is the MaxBufferSize of the writers of the
+	// scopes created with NewTriggerScope.
+	TriggerScopeMaxBufferSize = 256 * 1024
+
 	// FloatingPointPrecision, if set to a value other than -1, controls the number
 	// of digits when formatting float numbers in JSON. See strconv.FormatFloat for
 	// more details.
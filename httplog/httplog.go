@@ -0,0 +1,226 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package httplog provides net/http server middleware logging requests with
+// request-scoped loggers.
+package httplog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	log "github.com/luxfi/log"
+)
+
+// DefaultRequestIDHeader is the header carrying request IDs unless
+// Config.RequestIDHeader is set.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLen bounds the length of the propagated request IDs.
+const maxRequestIDLen = 128
+
+// Config configures the middleware. The zero value is ready to use.
+type Config struct {
+	// RequestIDHeader is the header a request ID is read from and written to
+	// the response under. DefaultRequestIDHeader is used if empty.
+	RequestIDHeader string
+
+	// GenerateRequestID returns the ID of the requests that do not carry a
+	// valid one. Random 16 hex digit IDs are generated if nil.
+	GenerateRequestID func() string
+
+	// SkipPaths lists the URL paths, such as "/health", whose requests are
+	// not logged.
+	SkipPaths []string
+
+	// Skip reports whether the request is not logged, in addition to
+	// SkipPaths.
+	Skip func(r *http.Request) bool
+}
+
+// Middleware returns middleware logging requests with l, with the default
+// Config. See MiddlewareWithConfig.
+func Middleware(l log.Logger) func(http.Handler) http.Handler {
+	return MiddlewareWithConfig(l, Config{})
+}
+
+// MiddlewareWithConfig returns middleware that, for each request:
+//
+//   - propagates the request ID of the request header, or generates one, and
+//     sets it on the response;
+//   - attaches a child of l with a request_id field to the request context
+//     with log.WithContext, for handlers to retrieve with log.Ctx;
+//   - attaches the span context of a W3C traceparent header to the request
+//     context, see log.ContextWithTraceparent;
+//   - recovers panics of the handler, logging them with a stack trace and
+//     responding 500 if nothing was written yet;
+//   - logs an access line with the method, path, status, bytes written,
+//     latency and remote address, at error level for 5xx responses and
+//     panics, the latter marked with panicked=true.
+//
+// Skipped requests still get a request ID and logger, and their panics are
+// still recovered, but no access line is logged for them.
+func MiddlewareWithConfig(l log.Logger, cfg Config) func(http.Handler) http.Handler {
+	header := cfg.RequestIDHeader
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	generate := cfg.GenerateRequestID
+	if generate == nil {
+		generate = newRequestID
+	}
+	skipPaths := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skipPaths[p] = struct{}{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(header)
+			if !validRequestID(id) {
+				id = generate()
+			}
+			w.Header().Set(header, id)
+
+			rl := l.New("request_id", id)
+			ctx := log.WithContext(r.Context(), rl)
+			if tp := r.Header.Get("traceparent"); tp != "" {
+				ctx, _ = log.ContextWithTraceparent(ctx, tp)
+			}
+			r = r.WithContext(ctx)
+
+			rw := &responseWriter{ResponseWriter: w}
+			_, skip := skipPaths[r.URL.Path]
+			skip = skip || (cfg.Skip != nil && cfg.Skip(r))
+
+			defer func() {
+				rec := recover()
+				if rec != nil {
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+					rl.ErrorEvent().
+						Interface("panic", rec).
+						Str(log.ErrorStackFieldName, string(debug.Stack())).
+						Str("method", r.Method).
+						Str("path", r.URL.Path).
+						Msg("http handler panicked")
+					if !rw.wroteHeader {
+						http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					}
+				}
+				if skip {
+					return
+				}
+
+				lvl := log.InfoLevel
+				if rw.status >= http.StatusInternalServerError || rec != nil {
+					lvl = log.ErrorLevel
+				}
+				e := rl.WithLevel(lvl).Ctx(ctx)
+				if rec != nil {
+					// The status may have been written before the panic.
+					e.Bool("panicked", true)
+				}
+				e.Str("method", r.Method).
+					Str("path", r.URL.Path).
+					Int("status", rw.statusCode()).
+					Int64("bytes", rw.bytes).
+					Dur("latency", time.Since(start)).
+					Str("remote", r.RemoteAddr).
+					Msg("http request")
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// validRequestID reports whether a request ID received from a client can be
+// propagated.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter records the status and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying writer does. The
+// response is then logged with status 101 unless a header was written.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
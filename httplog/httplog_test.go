@@ -0,0 +1,147 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package httplog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/luxfi/log"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.NewWriter(&buf)
+	mw := MiddlewareWithConfig(l, Config{SkipPaths: []string{"/health"}})
+
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("boom")
+		default:
+			log.Ctx(r.Context()).Info("handling")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("hello"))
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/items", nil)
+	req.Header.Set(DefaultRequestIDHeader, "abc")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(DefaultRequestIDHeader); got != "abc" {
+		t.Errorf("got request ID %q, want abc", got)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d after a panic, want 500", rec.Code)
+	}
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5:\n%s", len(lines), buf.String())
+	}
+
+	if lines[0]["message"] != "handling" || lines[0]["request_id"] != "abc" {
+		t.Errorf("unexpected handler line %v", lines[0])
+	}
+	access := lines[1]
+	if access["status"] != float64(201) || access["bytes"] != float64(5) ||
+		access["method"] != "POST" || access["path"] != "/items" || access["request_id"] != "abc" {
+		t.Errorf("unexpected access line %v", access)
+	}
+	// /health is skipped, but its handler line is logged.
+	if lines[2]["message"] != "handling" {
+		t.Errorf("unexpected line %v", lines[2])
+	}
+	if lines[3]["panic"] != "boom" || lines[3]["stack"] == nil {
+		t.Errorf("unexpected panic line %v", lines[3])
+	}
+	if lines[4]["status"] != float64(500) || lines[4]["level"] != "error" {
+		t.Errorf("unexpected access line %v", lines[4])
+	}
+	if id, _ := lines[4]["request_id"].(string); len(id) != 16 {
+		t.Errorf("unexpected generated request ID %q", id)
+	}
+}
+
+func TestMiddlewarePanicAfterWrite(t *testing.T) {
+	var buf bytes.Buffer
+	h := Middleware(log.NewWriter(&buf))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var access map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &access); err != nil {
+		t.Fatal(err)
+	}
+	if access["level"] != "error" || access["panicked"] != true || access["status"] != float64(200) {
+		t.Errorf("unexpected access line %v", access)
+	}
+}
+
+// hijackRecorder is a ResponseRecorder supporting http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	var buf bytes.Buffer
+	h := Middleware(log.NewWriter(&buf))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hijack":
+			if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+				t.Errorf("Hijack: %v", err)
+			}
+		case "/flush":
+			w.(http.Flusher).Flush()
+		default:
+			if _, _, err := w.(http.Hijacker).Hijack(); err != http.ErrNotSupported {
+				t.Errorf("Hijack error = %v, want %v", err, http.ErrNotSupported)
+			}
+		}
+	}))
+
+	hw := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(hw, httptest.NewRequest(http.MethodGet, "/hijack", nil))
+	if !hw.hijacked {
+		t.Error("the underlying writer was not hijacked")
+	}
+	fw := httptest.NewRecorder()
+	h.ServeHTTP(fw, httptest.NewRequest(http.MethodGet, "/flush", nil))
+	if !fw.Flushed {
+		t.Error("the underlying writer was not flushed")
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.Contains(buf.String(), `"status":101`) {
+		t.Errorf("no access line with status 101 for the hijacked request:\n%s", buf.String())
+	}
+}
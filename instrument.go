@@ -0,0 +1,334 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"expvar"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
+)
+
+// WriteLatencyBuckets are the upper bounds of the write latency histogram
+// kept by InstrumentedWriter.
+var WriteLatencyBuckets = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// instrumentedLevels are the levels counted individually by
+// InstrumentedWriter. Other numeric levels are counted together.
+var instrumentedLevels = [...]Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel, NoLevel}
+
+const otherLevelSlot = len(instrumentedLevels)
+
+// InstrumentedWriter wraps a writer and records the number of lines per
+// level, bytes, errors and write latency. Instrumented writers register
+// themselves so that their statistics are exported through expvar (under
+// "log_writers") and MetricsHandler, until they are unregistered or no longer
+// referenced.
+type InstrumentedWriter struct {
+	name string
+	sink string
+	w    LevelWriter
+
+	buckets      []time.Duration
+	lines        [otherLevelSlot + 1]atomic.Uint64
+	bytes        atomic.Uint64
+	errors       atomic.Uint64
+	latency      []atomic.Uint64 // one per bucket, plus +Inf
+	latencySumNs atomic.Int64
+}
+
+// WriterStats is a snapshot of the statistics of an InstrumentedWriter.
+type WriterStats struct {
+	// Name is the logger name the writer belongs to.
+	Name string
+	// Sink identifies the output, e.g. "file" or "console".
+	Sink string
+	// Lines is the number of lines written per level name. Lines without a
+	// level are counted under "none", custom numeric levels under "other".
+	Lines map[string]uint64
+	// Bytes is the number of bytes written.
+	Bytes uint64
+	// Errors is the number of failed writes.
+	Errors uint64
+	// Latency is the write latency histogram.
+	Latency LatencyHistogram
+}
+
+// LatencyHistogram is a write latency histogram. Counts[i] is the number of
+// writes that took at most Buckets[i] and more than Buckets[i-1]; the last
+// entry of Counts counts the writes slower than every bucket.
+type LatencyHistogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// instrumentedWriters holds weak pointers, so that the writers dropped
+// without being unregistered are garbage collected and removed.
+var (
+	instrumentedMu      sync.RWMutex
+	instrumentedWriters = make(map[weak.Pointer[InstrumentedWriter]]struct{})
+)
+
+func init() {
+	expvar.Publish("log_writers", expvar.Func(func() interface{} {
+		return InstrumentedWriterStats()
+	}))
+}
+
+// NewInstrumentedWriter wraps w and registers the result under the given
+// logger name and sink. The writer is unregistered by Unregister or Close, or
+// once it is garbage collected.
+func NewInstrumentedWriter(w io.Writer, name, sink string) *InstrumentedWriter {
+	lw, ok := w.(LevelWriter)
+	if !ok {
+		lw = LevelWriterAdapter{w}
+	}
+	iw := &InstrumentedWriter{
+		name:    name,
+		sink:    sink,
+		w:       lw,
+		buckets: append([]time.Duration(nil), WriteLatencyBuckets...),
+		latency: make([]atomic.Uint64, len(WriteLatencyBuckets)+1),
+	}
+	wp := weak.Make(iw)
+	instrumentedMu.Lock()
+	instrumentedWriters[wp] = struct{}{}
+	instrumentedMu.Unlock()
+	runtime.AddCleanup(iw, unregisterInstrumented, wp)
+	return iw
+}
+
+// Write implements the io.Writer interface. Lines written without a level are
+// counted as NoLevel.
+func (w *InstrumentedWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *InstrumentedWriter) WriteLevel(l Level, p []byte) (n int, err error) {
+	start := time.Now()
+	n, err = w.w.WriteLevel(l, p)
+	elapsed := time.Since(start)
+
+	w.lines[levelSlot(l)].Add(1)
+	w.bytes.Add(uint64(n))
+	if err != nil {
+		w.errors.Add(1)
+	}
+	i := 0
+	for i < len(w.buckets) && elapsed > w.buckets[i] {
+		i++
+	}
+	w.latency[i].Add(1)
+	w.latencySumNs.Add(int64(elapsed))
+	return n, err
+}
+
+func levelSlot(l Level) int {
+	for i, lvl := range instrumentedLevels {
+		if l == lvl {
+			return i
+		}
+	}
+	return otherLevelSlot
+}
+
+// Stats returns a snapshot of the writer statistics.
+func (w *InstrumentedWriter) Stats() WriterStats {
+	stats := WriterStats{
+		Name:   w.name,
+		Sink:   w.sink,
+		Lines:  make(map[string]uint64, len(w.lines)),
+		Bytes:  w.bytes.Load(),
+		Errors: w.errors.Load(),
+		Latency: LatencyHistogram{
+			Buckets: w.buckets,
+			Counts:  make([]uint64, len(w.latency)),
+			Sum:     time.Duration(w.latencySumNs.Load()),
+		},
+	}
+	for i := range w.lines {
+		if n := w.lines[i].Load(); n > 0 {
+			stats.Lines[levelSlotName(i)] = n
+		}
+	}
+	for i := range w.latency {
+		stats.Latency.Counts[i] = w.latency[i].Load()
+		stats.Latency.Count += stats.Latency.Counts[i]
+	}
+	return stats
+}
+
+func levelSlotName(i int) string {
+	if i == otherLevelSlot {
+		return "other"
+	}
+	if l := instrumentedLevels[i]; l != NoLevel {
+		return l.String()
+	}
+	return "none"
+}
+
+// Unregister removes the writer from the exported statistics.
+func (w *InstrumentedWriter) Unregister() {
+	unregisterInstrumented(weak.Make(w))
+}
+
+func unregisterInstrumented(wp weak.Pointer[InstrumentedWriter]) {
+	instrumentedMu.Lock()
+	delete(instrumentedWriters, wp)
+	instrumentedMu.Unlock()
+}
+
+// Close unregisters the writer and closes the underlying writer if it is an
+// io.Closer.
+func (w *InstrumentedWriter) Close() error {
+	w.Unregister()
+	if closer, ok := w.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Sync calls the Sync method of the underlying writer if it has one.
+func (w *InstrumentedWriter) Sync() error {
+	return syncOutput(w.w)
+}
+
+// InstrumentedWriterStats returns the statistics of every registered
+// InstrumentedWriter, sorted by name and sink.
+func InstrumentedWriterStats() []WriterStats {
+	instrumentedMu.RLock()
+	stats := make([]WriterStats, 0, len(instrumentedWriters))
+	for wp := range instrumentedWriters {
+		if w := wp.Value(); w != nil {
+			stats = append(stats, w.Stats())
+		}
+	}
+	instrumentedMu.RUnlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Name != stats[j].Name {
+			return stats[i].Name < stats[j].Name
+		}
+		return stats[i].Sink < stats[j].Sink
+	})
+	return stats
+}
+
+// MetricsHandler returns an http.Handler exposing the statistics of every
+// registered InstrumentedWriter in the Prometheus text exposition format.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(appendMetrics(nil, InstrumentedWriterStats()))
+	})
+}
+
+func appendMetrics(dst []byte, stats []WriterStats) []byte {
+	b := bytes.NewBuffer(dst)
+
+	b.WriteString("# HELP log_writer_lines_total Number of log lines written.\n")
+	b.WriteString("# TYPE log_writer_lines_total counter\n")
+	for _, s := range stats {
+		levels := make([]string, 0, len(s.Lines))
+		for lvl := range s.Lines {
+			levels = append(levels, lvl)
+		}
+		sort.Strings(levels)
+		for _, lvl := range levels {
+			writeMetric(b, "log_writer_lines_total", s, "level", lvl, strconv.FormatUint(s.Lines[lvl], 10))
+		}
+	}
+
+	b.WriteString("# HELP log_writer_bytes_total Number of log bytes written.\n")
+	b.WriteString("# TYPE log_writer_bytes_total counter\n")
+	for _, s := range stats {
+		writeMetric(b, "log_writer_bytes_total", s, "", "", strconv.FormatUint(s.Bytes, 10))
+	}
+
+	b.WriteString("# HELP log_writer_errors_total Number of failed log writes.\n")
+	b.WriteString("# TYPE log_writer_errors_total counter\n")
+	for _, s := range stats {
+		writeMetric(b, "log_writer_errors_total", s, "", "", strconv.FormatUint(s.Errors, 10))
+	}
+
+	b.WriteString("# HELP log_writer_write_seconds Log write latency.\n")
+	b.WriteString("# TYPE log_writer_write_seconds histogram\n")
+	for _, s := range stats {
+		var cumulative uint64
+		for i, bound := range s.Latency.Buckets {
+			cumulative += s.Latency.Counts[i]
+			le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+			writeMetric(b, "log_writer_write_seconds_bucket", s, "le", le, strconv.FormatUint(cumulative, 10))
+		}
+		writeMetric(b, "log_writer_write_seconds_bucket", s, "le", "+Inf", strconv.FormatUint(s.Latency.Count, 10))
+		writeMetric(b, "log_writer_write_seconds_sum", s, "", "", strconv.FormatFloat(s.Latency.Sum.Seconds(), 'g', -1, 64))
+		writeMetric(b, "log_writer_write_seconds_count", s, "", "", strconv.FormatUint(s.Latency.Count, 10))
+	}
+
+	return b.Bytes()
+}
+
+func writeMetric(b *bytes.Buffer, metric string, s WriterStats, label, value, sample string) {
+	b.WriteString(metric)
+	b.WriteString(`{logger="`)
+	b.WriteString(escapeLabelValue(s.Name))
+	b.WriteString(`",sink="`)
+	b.WriteString(escapeLabelValue(s.Sink))
+	b.WriteByte('"')
+	if label != "" {
+		b.WriteByte(',')
+		b.WriteString(label)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(value))
+		b.WriteByte('"')
+	}
+	b.WriteString("} ")
+	b.WriteString(sample)
+	b.WriteByte('\n')
+}
+
+func escapeLabelValue(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' && c != '"' && c != '\n' {
+			if b != nil {
+				b = append(b, c)
+			}
+			continue
+		}
+		if b == nil {
+			b = append(make([]byte, 0, len(s)+2), s[:i]...)
+		}
+		switch c {
+		case '\n':
+			b = append(b, '\\', 'n')
+		default:
+			b = append(b, '\\', c)
+		}
+	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
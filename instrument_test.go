@@ -0,0 +1,136 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("write failed") }
+
+// registeredStats returns the stats of the registered writers named name.
+func registeredStats(name string) []WriterStats {
+	var stats []WriterStats
+	for _, s := range InstrumentedWriterStats() {
+		if s.Name == name {
+			stats = append(stats, s)
+		}
+	}
+	return stats
+}
+
+func TestInstrumentedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewInstrumentedWriter(&buf, "instrumented", "buffer")
+	defer w.Unregister()
+
+	l := NewWriter(w)
+	l.Info("a")
+	l.Info("b")
+	l.Warn("c")
+	w.Write([]byte("raw\n"))
+	w.WriteLevel(Level(42), []byte("custom\n"))
+
+	s := w.Stats()
+	wantLines := map[string]uint64{"info": 2, "warn": 1, "none": 1, "other": 1}
+	if len(s.Lines) != len(wantLines) {
+		t.Errorf("Lines = %v, want %v", s.Lines, wantLines)
+	}
+	for lvl, n := range wantLines {
+		if s.Lines[lvl] != n {
+			t.Errorf("Lines[%s] = %d, want %d", lvl, s.Lines[lvl], n)
+		}
+	}
+	if s.Bytes != uint64(buf.Len()) || s.Errors != 0 {
+		t.Errorf("Bytes = %d, Errors = %d, want %d, 0", s.Bytes, s.Errors, buf.Len())
+	}
+	if s.Latency.Count != 5 || len(s.Latency.Counts) != len(WriteLatencyBuckets)+1 {
+		t.Errorf("Latency = %+v, want 5 writes in %d buckets", s.Latency, len(WriteLatencyBuckets)+1)
+	}
+
+	fw := NewInstrumentedWriter(failingWriter{}, "instrumented", "failing")
+	defer fw.Unregister()
+	if _, err := fw.WriteLevel(InfoLevel, []byte("x\n")); err == nil {
+		t.Error("write error not returned")
+	}
+	if s := fw.Stats(); s.Errors != 1 || s.Lines["info"] != 1 {
+		t.Errorf("failing writer stats = %+v", s)
+	}
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`log_writer_lines_total{logger="instrumented",sink="buffer",level="info"} 2`,
+		`log_writer_errors_total{logger="instrumented",sink="failing"} 1`,
+		`log_writer_write_seconds_count{logger="instrumented",sink="buffer"} 5`,
+		`log_writer_write_seconds_bucket{logger="instrumented",sink="buffer",le="+Inf"} 5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestInstrumentedWriterRegistry(t *testing.T) {
+	w := NewInstrumentedWriter(&bytes.Buffer{}, "registry", "kept")
+	if got := registeredStats("registry"); len(got) != 1 {
+		t.Fatalf("registered %d writers, want 1", len(got))
+	}
+	w.Unregister()
+	if got := registeredStats("registry"); len(got) != 0 {
+		t.Fatalf("writer still registered after Unregister")
+	}
+
+	// Writers dropped without being unregistered do not leak.
+	NewInstrumentedWriter(&bytes.Buffer{}, "registry", "dropped")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runtime.GC()
+		instrumentedMu.RLock()
+		n := 0
+		for wp := range instrumentedWriters {
+			if w := wp.Value(); w == nil || w.name == "registry" {
+				n++
+			}
+		}
+		instrumentedMu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("dropped writer never unregistered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFactoryLeavesStderrOpen(t *testing.T) {
+	for _, format := range []LogFormat{JSON, Plain} {
+		f := NewFactoryWithConfig(Config{
+			RotatingWriterConfig: RotatingWriterConfig{Directory: t.TempDir()},
+			LogLevel:             InfoLevel,
+			LogFormat:            format,
+		})
+		l, err := f.Make("stderr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Fatal events close the writer of the logger before exiting.
+		l.With().ExitFunc(func(int) {}).Logger().Fatal("expected fatal line, not a failure")
+		f.Close()
+		if _, err := os.Stderr.Stat(); err != nil {
+			t.Fatalf("os.Stderr closed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Lazy is a value computed only if the event it is logged with is enabled,
+// compatible with go-ethereum:
+//
+//	l.Debug("state", "root", log.Lazy{Fn: func() common.Hash { return trie.Hash() }})
+//
+// Fn must be a function without arguments. Its result is logged as if it had
+// been passed directly, or its results as an array if it returns several.
+// Use LazyT to avoid reflection.
+//
+// Lazy values are recognized in key-value pairs, Field values and the fields
+// of Event.Fields and Context.Fields, the latter being computed once when the
+// logger is created. If Fn panics, the panic is logged as an error value.
+type Lazy struct {
+	Fn interface{}
+}
+
+// LazyT is Lazy for a function of known type.
+type LazyT[T any] struct {
+	Fn func() T
+}
+
+// lazyValue is implemented by Lazy and LazyT.
+type lazyValue interface {
+	evaluate() (interface{}, error)
+}
+
+var errLazyFn = errors.New("log.Lazy: Fn must be a function without arguments returning a value")
+
+func (l Lazy) evaluate() (interface{}, error) {
+	v := reflect.ValueOf(l.Fn)
+	if v.Kind() != reflect.Func || v.IsNil() || v.Type().NumIn() > 0 || v.Type().NumOut() == 0 {
+		return nil, errLazyFn
+	}
+	out := v.Call(nil)
+	if len(out) == 1 {
+		return out[0].Interface(), nil
+	}
+	vals := make([]interface{}, len(out))
+	for i, o := range out {
+		vals[i] = o.Interface()
+	}
+	return vals, nil
+}
+
+func (l LazyT[T]) evaluate() (interface{}, error) {
+	if l.Fn == nil {
+		return nil, errLazyFn
+	}
+	return l.Fn(), nil
+}
+
+// resolveLazy returns the value of v if it is a Lazy or LazyT, or v itself.
+// Errors and panics are returned as error values.
+func resolveLazy(v interface{}) (val interface{}) {
+	lv, ok := v.(lazyValue)
+	if !ok {
+		return v
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			val = fmt.Errorf("log.Lazy: panic: %v", r)
+		}
+	}()
+	val, err := lv.evaluate()
+	if err != nil {
+		return err
+	}
+	return val
+}
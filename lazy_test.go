@@ -0,0 +1,34 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel)
+
+	calls := 0
+	count := LazyT[int]{Fn: func() int { calls++; return calls }}
+	l.Debug("disabled", "n", count)
+	if calls != 0 {
+		t.Fatal("lazy value of a disabled event was evaluated")
+	}
+
+	l.Info("kv", "n", count, "pair", Lazy{Fn: func() (string, int) { return "a", 1 }})
+	l.Info("field", Any("n", count), "bad", Lazy{Fn: 42})
+	l.Info("panic", "p", Lazy{Fn: func() string { panic("boom") }})
+	l.New("n", count).Info("context")
+
+	want := `{"level":"info","n":1,"pair":["a",1],"message":"kv"}` + "\n" +
+		`{"level":"info","n":2,"bad":"` + errLazyFn.Error() + `","message":"field"}` + "\n" +
+		`{"level":"info","p":"log.Lazy: panic: boom","message":"panic"}` + "\n" +
+		`{"level":"info","n":3,"message":"context"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
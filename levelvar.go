@@ -0,0 +1,200 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"weak"
+)
+
+// LevelVar is a Level variable shared by a logger and every child logger
+// derived from it with With, New, Hook, Sample or Output, so that changing it
+// at runtime takes effect immediately everywhere. It is safe for concurrent
+// use.
+//
+// Logger.Level returns a child with its own LevelVar, detached from its
+// parent.
+//
+// A LevelVar created with Child follows the level of its parent until Set is
+// called on it.
+type LevelVar struct {
+	val        atomic.Int32
+	parent     *LevelVar
+	overridden atomic.Bool
+
+	mu        sync.Mutex
+	listeners map[uint64]func(old, new Level)
+	nextID    uint64
+	// children holds the LevelVars created with Child, weakly so that
+	// they are freed with the loggers using them.
+	children map[weak.Pointer[LevelVar]]struct{}
+}
+
+// NewLevelVar creates a LevelVar set to l.
+func NewLevelVar(l Level) *LevelVar {
+	v := &LevelVar{}
+	v.val.Store(int32(l))
+	return v
+}
+
+// Child returns a LevelVar following the level of v until its own level is
+// set. Listeners of the child are notified of the changes of v it follows.
+// The child is not referenced by v, so it is freed once no longer used.
+func (v *LevelVar) Child() *LevelVar {
+	c := &LevelVar{parent: v}
+	wp := weak.Make(c)
+	v.mu.Lock()
+	if v.children == nil {
+		v.children = make(map[weak.Pointer[LevelVar]]struct{})
+	}
+	v.children[wp] = struct{}{}
+	v.mu.Unlock()
+	runtime.AddCleanup(c, v.removeChild, wp)
+	return c
+}
+
+func (v *LevelVar) removeChild(wp weak.Pointer[LevelVar]) {
+	v.mu.Lock()
+	delete(v.children, wp)
+	v.mu.Unlock()
+}
+
+// Level returns the current level.
+func (v *LevelVar) Level() Level {
+	for v.parent != nil && !v.overridden.Load() {
+		v = v.parent
+	}
+	return Level(v.val.Load())
+}
+
+// Set changes the level and notifies the listeners registered with OnChange
+// if it differs from the current one. A LevelVar created with Child stops
+// following its parent.
+func (v *LevelVar) Set(l Level) {
+	var old Level
+	if v.parent == nil {
+		old = Level(v.val.Swap(int32(l)))
+	} else {
+		old = v.Level()
+		v.val.Store(int32(l))
+		v.overridden.Store(true)
+	}
+	if old != l {
+		v.notify(old, l)
+	}
+}
+
+// Inherit makes a LevelVar created with Child follow its parent again. It
+// does nothing on other LevelVars.
+func (v *LevelVar) Inherit() {
+	if v.parent == nil {
+		return
+	}
+	old := v.Level()
+	v.overridden.Store(false)
+	if l := v.Level(); old != l {
+		v.notify(old, l)
+	}
+}
+
+// notify calls the listeners of v, and of the children following it.
+func (v *LevelVar) notify(old, new Level) {
+	v.mu.Lock()
+	listeners := make([]func(old, new Level), 0, len(v.listeners))
+	for _, fn := range v.listeners {
+		listeners = append(listeners, fn)
+	}
+	children := make([]*LevelVar, 0, len(v.children))
+	for wp := range v.children {
+		if c := wp.Value(); c != nil {
+			children = append(children, c)
+		}
+	}
+	v.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(old, new)
+	}
+	for _, c := range children {
+		if !c.overridden.Load() {
+			c.notify(old, new)
+		}
+	}
+}
+
+// OnChange registers fn to be called after each level change. The returned
+// function unregisters it.
+func (v *LevelVar) OnChange(fn func(old, new Level)) (cancel func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.listeners == nil {
+		v.listeners = make(map[uint64]func(old, new Level))
+	}
+	id := v.nextID
+	v.nextID++
+	v.listeners[id] = fn
+	return func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		delete(v.listeners, id)
+	}
+}
+
+// String returns the name of the current level.
+func (v *LevelVar) String() string {
+	return v.Level().String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (v *LevelVar) MarshalText() ([]byte, error) {
+	return v.Level().MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (v *LevelVar) UnmarshalText(text []byte) error {
+	var l Level
+	if err := l.UnmarshalText(text); err != nil {
+		return err
+	}
+	v.Set(l)
+	return nil
+}
+
+// WithLevelVar returns a child of l whose level is read from v. Changing v
+// changes the level of the returned logger and of all its children, and the
+// named level rules do not apply to the returned logger. Loggers not created
+// by this package get a child at the current level of v instead.
+// The child of Root, or of a logger derived from it, follows SetDefault.
+func WithLevelVar(l Logger, v *LevelVar) Logger {
+	if p, ok := l.(*rootProxy); ok {
+		return p.with(func(l Logger) Logger { return WithLevelVar(l, v) })
+	}
+	ll, ok := l.(*logger)
+	if !ok {
+		return l.Level(v.Level())
+	}
+	return &logger{
+		w:       ll.w,
+		level:   v,
+		name:    explicitName(ll.name),
+		sampler: ll.sampler,
+		context: ll.context,
+		hooks:   ll.hooks,
+		stack:   ll.stack,
+		ctx:     ll.ctx,
+		exit:    ll.exit,
+	}
+}
+
+// LevelVarOf returns the LevelVar l reads its level from, or nil if l was not
+// created by this package.
+func LevelVarOf(l Logger) *LevelVar {
+	if ll, ok := resolveRoot(l).(*logger); ok {
+		return ll.level
+	}
+	return nil
+}
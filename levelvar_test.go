@@ -0,0 +1,64 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLevelVarChild(t *testing.T) {
+	parent := NewLevelVar(InfoLevel)
+	child := parent.Child()
+	var changes []Level
+	child.OnChange(func(old, new Level) { changes = append(changes, new) })
+
+	parent.Set(DebugLevel)
+	child.Set(WarnLevel)
+	parent.Set(ErrorLevel)
+	child.Inherit()
+	if child.Level() != ErrorLevel {
+		t.Errorf("Level() = %v after Inherit, want %v", child.Level(), ErrorLevel)
+	}
+	want := []Level{DebugLevel, WarnLevel, ErrorLevel}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v, want %v", changes, want)
+		}
+	}
+	runtime.KeepAlive(child)
+}
+
+func TestLevelVarChildrenAreFreed(t *testing.T) {
+	parent := NewLevelVar(InfoLevel)
+	for i := 0; i < 100; i++ {
+		parent.Child()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runtime.GC()
+		parent.mu.Lock()
+		n := len(parent.children)
+		parent.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d children still registered", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoggerLevelAllocs(t *testing.T) {
+	l := NewWriter(io.Discard)
+	if n := testing.AllocsPerRun(100, func() { _ = l.Level(InfoLevel) }); n != 1 {
+		t.Errorf("Level() allocates %v times, want 1", n)
+	}
+}
@@ -26,6 +26,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ErrUnknownLevel is returned when parsing an unknown log level string.
@@ -134,6 +137,10 @@ func (l Level) MarshalText() ([]byte, error) {
 
 // Logger is the primary logging interface. All logging implementations
 // satisfy this interface. Use == nil to check for uninitialized loggers.
+//
+// Methods are added to Logger as the package grows, such as Named and Start,
+// so implementations outside this package should embed a Logger, such as
+// Noop(), to keep compiling.
 type Logger interface {
 	// Geth-style variadic logging methods
 	Trace(msg string, ctx ...interface{})
@@ -150,8 +157,15 @@ type Logger interface {
 	// Context/child loggers
 	With() Context
 	New(ctx ...interface{}) Logger
+	Named(name string) Logger
+	Ctx(ctx context.Context) Logger
 	Output(w io.Writer) Logger
 
+	// Start logs the start of an operation at debug level and returns its
+	// span, logged by Span.End, and a copy of ctx carrying it, so that the
+	// spans started with it are nested, and its logger, see WithContext.
+	Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span)
+
 	// Level control
 	Level(lvl Level) Logger
 	GetLevel() Level
@@ -172,6 +186,9 @@ type Logger interface {
 	// Utilities
 	Sample(s Sampler) Logger
 	Hook(hooks ...Hook) Logger
+	Every(interval time.Duration) Throttle
+	FirstN(n int) Throttle
+	Once(key string) Once
 	Print(v ...interface{})
 	Printf(format string, v ...interface{})
 	Write(p []byte) (n int, err error)
@@ -230,12 +247,14 @@ func InitLogger(chainAlias string, logLevel string, jsonFormat bool, writer io.W
 // logger is the internal implementation of Logger interface.
 type logger struct {
 	w       LevelWriter
-	level   Level
+	level   *LevelVar
+	name    *loggerName
 	sampler Sampler
 	context []byte
 	hooks   []Hook
 	stack   bool
 	ctx     context.Context
+	exit    func(code int)
 }
 
 // newLogger creates a new logger with the given writer.
@@ -247,15 +266,17 @@ func newLogger(w io.Writer) *logger {
 	if !ok {
 		lw = LevelWriterAdapter{w}
 	}
-	return &logger{w: lw, level: TraceLevel}
+	return &logger{w: lw, level: NewLevelVar(TraceLevel)}
 }
 
 // Output duplicates the current logger and sets w as its output.
 func (l *logger) Output(w io.Writer) Logger {
 	l2 := newLogger(w)
 	l2.level = l.level
+	l2.name = l.name
 	l2.sampler = l.sampler
 	l2.stack = l.stack
+	l2.exit = l.exit
 	if len(l.hooks) > 0 {
 		l2.hooks = append(l2.hooks, l.hooks...)
 	}
@@ -278,30 +299,49 @@ func (l *logger) With() Context {
 	return Context{&logger{
 		w:       l.w,
 		level:   l.level,
+		name:    l.name,
 		sampler: l.sampler,
 		context: newCtx,
 		hooks:   l.hooks,
 		stack:   l.stack,
 		ctx:     l.ctx,
+		exit:    l.exit,
 	}}
 }
 
 // Level creates a child logger with the minimum accepted level set to level.
+// The child gets its own LevelVar: later changes to the level of l do not
+// affect it. The named level rules do not apply to the child.
 func (l *logger) Level(lvl Level) Logger {
-	return &logger{
+	// The logger, its LevelVar and its name are allocated together.
+	c := &struct {
+		logger
+		level LevelVar
+		name  loggerName
+	}{}
+	c.level.val.Store(int32(lvl))
+	c.logger = logger{
 		w:       l.w,
-		level:   lvl,
+		level:   &c.level,
 		sampler: l.sampler,
 		context: l.context,
 		hooks:   l.hooks,
 		stack:   l.stack,
 		ctx:     l.ctx,
+		exit:    l.exit,
 	}
+	if l.name != nil {
+		c.name.name = l.name.name
+		c.name.explicit.Store(true)
+		c.logger.name = &c.name
+	}
+	return &c.logger
 }
 
-// GetLevel returns the current Level of l.
+// GetLevel returns the current Level of l, or the level the named level
+// rules assign to it.
 func (l *logger) GetLevel() Level {
-	return l.level
+	return l.threshold()
 }
 
 // IsZero returns true if the logger is disabled or uninitialized.
@@ -317,24 +357,85 @@ func (l *logger) New(ctx ...interface{}) Logger {
 	return l
 }
 
+// Named creates a child logger named after l's name and name, joined with a
+// dot: Named("p2p").Named("gossip") is named "p2p.gossip". The name is added to
+// each event under LoggerFieldName, and levels set with SetNamedLevels for a
+// matching rule take precedence over the level the child inherits from l.
+func (l *logger) Named(name string) Logger {
+	if l.name != nil && l.name.name != "" {
+		name = l.name.name + "." + name
+	}
+	return &logger{
+		w:       l.w,
+		level:   l.level,
+		name:    &loggerName{name: name},
+		sampler: l.sampler,
+		context: l.context,
+		hooks:   l.hooks,
+		stack:   l.stack,
+		ctx:     l.ctx,
+		exit:    l.exit,
+	}
+}
+
+// Ctx creates a child logger carrying ctx for hooks, with the fields attached
+// to ctx with ContextWith added to its context.
+func (l *logger) Ctx(ctx context.Context) Logger {
+	return l.With().Ctx(ctx).Logger()
+}
+
+// Start starts a span of the operation name, with the fields kv.
+func (l *logger) Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return startSpan(l, ctx, name, kv)
+}
+
 // Enabled checks if the given level is enabled for this logger.
 func (l *logger) Enabled(ctx context.Context, level slog.Level) bool {
-	var internalLevel Level
+	lvl := levelFromSlog(level)
+	if l.should(lvl) {
+		return true
+	}
+	if ctx == nil {
+		ctx = l.ctx
+	}
+	return l.forcedAt(lvl, ctx)
+}
+
+// levelFromSlog converts a slog.Level to the closest Level at or above it.
+func levelFromSlog(level slog.Level) Level {
 	switch {
 	case level <= slogLevelTrace:
-		internalLevel = TraceLevel
+		return TraceLevel
 	case level <= slog.LevelDebug:
-		internalLevel = DebugLevel
+		return DebugLevel
 	case level <= slog.LevelInfo:
-		internalLevel = InfoLevel
+		return InfoLevel
 	case level <= slog.LevelWarn:
-		internalLevel = WarnLevel
+		return WarnLevel
 	case level <= slog.LevelError:
-		internalLevel = ErrorLevel
+		return ErrorLevel
 	default:
-		internalLevel = FatalLevel
+		return FatalLevel
+	}
+}
+
+// levelToSlog converts a Level to a slog.Level, mapping the levels above
+// ErrorLevel to crit.
+func levelToSlog(level Level) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel, NoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel, PanicLevel, Disabled:
+		return slogLevelCrit
+	default:
+		return slogLevelTrace
 	}
-	return l.should(internalLevel)
 }
 
 // Sample returns a logger with the s sampler.
@@ -342,11 +443,13 @@ func (l *logger) Sample(s Sampler) Logger {
 	return &logger{
 		w:       l.w,
 		level:   l.level,
+		name:    l.name,
 		sampler: s,
 		context: l.context,
 		hooks:   l.hooks,
 		stack:   l.stack,
 		ctx:     l.ctx,
+		exit:    l.exit,
 	}
 }
 
@@ -365,11 +468,13 @@ func (l *logger) hook(hooks ...Hook) *logger {
 	return &logger{
 		w:       l.w,
 		level:   l.level,
+		name:    l.name,
 		sampler: l.sampler,
 		context: l.context,
 		hooks:   append(newHooks, hooks...),
 		stack:   l.stack,
 		ctx:     l.ctx,
+		exit:    l.exit,
 	}
 }
 
@@ -406,18 +511,13 @@ func (l *logger) Error(msg string, ctx ...interface{}) {
 }
 
 func (l *logger) Fatal(msg string, ctx ...interface{}) {
-	if e := l.newEvent(FatalLevel, func(msg string) {
-		if closer, ok := l.w.(io.Closer); ok {
-			closer.Close()
-		}
-		os.Exit(1)
-	}); e != nil {
+	if e := l.newEvent(FatalLevel, l.fatalDone); e != nil {
 		applyContext(e, ctx).Msg(msg)
 	}
 }
 
 func (l *logger) Panic(msg string, ctx ...interface{}) {
-	if e := l.newEvent(PanicLevel, func(msg string) { panic(msg) }); e != nil {
+	if e := l.newEvent(PanicLevel, l.panicDone); e != nil {
 		applyContext(e, ctx).Msg(msg)
 	}
 }
@@ -459,16 +559,23 @@ func (l *logger) ErrorEvent() *Event {
 }
 
 func (l *logger) FatalEvent() *Event {
-	return l.newEvent(FatalLevel, func(msg string) {
-		if closer, ok := l.w.(io.Closer); ok {
-			closer.Close()
-		}
-		os.Exit(1)
-	})
+	return l.newEvent(FatalLevel, l.fatalDone)
 }
 
 func (l *logger) PanicEvent() *Event {
-	return l.newEvent(PanicLevel, func(msg string) { panic(msg) })
+	return l.newEvent(PanicLevel, l.panicDone)
+}
+
+// fatalDone ends the process once a Fatal event is written, see fatalExit.
+func (l *logger) fatalDone(string) {
+	fatalExit(l.w, l.exit)
+}
+
+// panicDone syncs the writer so that the event survives an unrecovered
+// panic, then panics with the message.
+func (l *logger) panicDone(msg string) {
+	_ = syncOutput(l.w)
+	panic(msg)
 }
 
 func (l *logger) Err(err error) *Event {
@@ -550,20 +657,54 @@ func (l *logger) RecoverAndPanic(fn func()) {
 }
 
 func (l *logger) newEvent(level Level, done func(string)) *Event {
-	enabled := l.should(level)
-	if !enabled {
-		if done != nil {
-			done("")
-		}
+	// Below the level of the logger, only rules or a forced level can enable
+	// the event.
+	if level < l.level.Level() && l.ctx == nil && done == nil && !levelOverrides.Load() {
 		return nil
 	}
-	e := newEvent(l.w, level, l.stack, l.ctx, l.hooks)
+	return l.newEventAt(level, 0, nil, done)
+}
+
+// newEventAt is newEvent for an event logged from pc, or from the caller of
+// the logger if pc is 0.
+// The event is logged despite the levels and sampler if ctx, or the context
+// of the logger if ctx is nil, forces its level.
+func (l *logger) newEventAt(level Level, pc uintptr, ctx context.Context, done func(string)) *Event {
+	if ctx == nil {
+		ctx = l.ctx
+	}
+	forced := false
+	if !l.shouldAt(level, pc) {
+		if !l.forcedAt(level, ctx) {
+			if done != nil {
+				done("")
+			}
+			return nil
+		}
+		// Only the events below the level are marked, not those the
+		// sampler would have dropped.
+		passes, _ := l.levelPasses(level)
+		forced = !passes
+	}
+	e := newEvent(l.w, level, l.stack, ctx, l.hooks)
 	e.done = done
+	// The fields of the context given to the logger are in its context.
+	e.applied = ctxFields(l.ctx)
 	if level != NoLevel && LevelFieldName != "" {
 		e.Str(LevelFieldName, LevelFieldMarshalFunc(level))
 	}
+	if forced && ForcedFieldName != "" {
+		e.Bool(ForcedFieldName, true)
+	}
+	if l.name != nil && LoggerFieldName != "" {
+		e.Str(LoggerFieldName, l.name.name)
+	}
 	if len(l.context) > 1 {
+		start := len(e.buf)
 		e.buf = enc.AppendObjectData(e.buf, l.context)
+		if e.typed {
+			e.marks = append(e.marks, slogMark{start: start, end: len(e.buf), context: true})
+		}
 	}
 	return e
 }
@@ -575,18 +716,97 @@ func (l *logger) scratchEvent() *Event {
 }
 
 func (l *logger) should(lvl Level) bool {
+	return l.shouldAt(lvl, 0)
+}
+
+// shouldAt is should for an event logged from pc, or from the caller of the
+// logger if pc is 0.
+func (l *logger) shouldAt(lvl Level, pc uintptr) bool {
+	if !l.enabledAt(lvl, pc) {
+		return false
+	}
+	if l.sampler != nil && !samplingDisabled() {
+		return l.sampler.Sample(lvl)
+	}
+	return true
+}
+
+// enabledAt is shouldAt without the sampler.
+func (l *logger) enabledAt(lvl Level, pc uintptr) bool {
 	if l.w == nil {
 		return false
 	}
-	if lvl < l.level || lvl < GlobalLevel() {
+	if ok, disabled := l.levelPasses(lvl); !ok {
+		// Call sites enabled by vmodule rules or dynamic debug log below the
+		// logger and global levels, unless logging is disabled altogether.
+		if disabled || !callSiteEnabled(lvl, pc) {
+			return false
+		}
+	} else if lvl <= DebugLevel && dynamicDebug.Load() {
+		if s := siteAt(pc); s != nil {
+			s.record(lvl)
+		}
+	}
+	if w, ok := l.w.(levelCtxWriter); ok && !w.enabledLevel(lvl) {
 		return false
 	}
-	if l.sampler != nil && !samplingDisabled() {
-		return l.sampler.Sample(lvl)
+	return true
+}
+
+// forcedAt reports whether ctx forces lvl to be logged, see WithForcedLevel.
+// Logging must not be disabled altogether, and a slog handler written to must
+// accept lvl.
+func (l *logger) forcedAt(lvl Level, ctx context.Context) bool {
+	forced, ok := ForcedLevel(ctx)
+	if !ok || lvl < forced || l.w == nil || lvl == Disabled {
+		return false
+	}
+	if _, disabled := l.levelPasses(lvl); disabled {
+		return false
+	}
+	if w, ok := l.w.(levelCtxWriter); ok && !w.enabledLevel(lvl) {
+		return false
 	}
 	return true
 }
 
+// levelPasses reports whether lvl is at or above the level of the logger, or
+// the named level rule matching it, and the global level, and whether either
+// of them is Disabled.
+func (l *logger) levelPasses(lvl Level) (ok, disabled bool) {
+	threshold := l.threshold()
+	global := GlobalLevel()
+	return lvl >= threshold && lvl >= global, threshold == Disabled || global == Disabled
+}
+
+var (
+	// levelOverrides is set while vmodule rules, dynamic debug or named
+	// level rules may enable events below the level of a logger.
+	levelOverrides   atomic.Bool
+	levelOverridesMu sync.Mutex
+)
+
+// updateLevelOverrides sets levelOverrides from the current rules. It must be
+// called after each change of them.
+func updateLevelOverrides() {
+	levelOverridesMu.Lock()
+	defer levelOverridesMu.Unlock()
+	named := namedLevels.Load()
+	levelOverrides.Store(vmodule.Load() != nil || dynamicDebug.Load() ||
+		(named != nil && len(named.rules) > 0))
+}
+
+// threshold returns the level of the logger, or the level the named level
+// rules assign to its name. The rules do not enable a Disabled logger.
+func (l *logger) threshold() Level {
+	if l.name != nil && l.level.Level() != Disabled {
+		if named, ok := l.name.level(); ok {
+			return named
+		}
+	}
+	return l.level.Level()
+}
+
 // =============================================================================
 // noopLogger - disabled logger implementation
 // =============================================================================
@@ -605,6 +825,8 @@ func (noopLogger) Verbo(string, ...interface{})             {}
 func (noopLogger) Log(Level, string, ...interface{})        {}
 func (n noopLogger) With() Context                          { return Context{} }
 func (n noopLogger) New(...interface{}) Logger              { return n }
+func (n noopLogger) Named(string) Logger                    { return n }
+func (n noopLogger) Ctx(context.Context) Logger             { return n }
 func (n noopLogger) Output(io.Writer) Logger                { return n }
 func (n noopLogger) Level(Level) Logger                     { return n }
 func (noopLogger) GetLevel() Level                          { return Disabled }
@@ -634,3 +856,19 @@ func (noopLogger) RecoverAndPanic(fn func()) {
 	}()
 	fn()
 }
+
+func (n noopLogger) Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return startSpan(n, ctx, name, kv)
+}
+
+func (n noopLogger) Every(interval time.Duration) Throttle {
+	return Throttle{l: n, every: interval}
+}
+
+func (n noopLogger) FirstN(count int) Throttle {
+	return Throttle{l: n, firstN: true, n: count}
+}
+
+func (n noopLogger) Once(key string) Once {
+	return Once{l: n, key: key}
+}
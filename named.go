@@ -0,0 +1,178 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// namedLevelRule sets the level of the loggers whose name matches pattern.
+type namedLevelRule struct {
+	pattern string
+	glob    bool
+	level   Level
+}
+
+// namedLevelRules is an immutable set of rules. gen changes with every
+// SetNamedLevels call so that loggers can cache their resolved level.
+type namedLevelRules struct {
+	spec  string
+	rules []namedLevelRule
+	gen   uint64
+}
+
+var (
+	namedLevels   atomic.Pointer[namedLevelRules]
+	namedLevelGen atomic.Uint64
+)
+
+// SetNamedLevels replaces the level rules of named loggers (see Logger.Named).
+// spec is a comma-separated list of name=level rules, such as
+// "p2p.*=debug,chain.C=trace". A pattern without wildcards matches the logger
+// of that name and its descendants ("p2p" matches "p2p" and "p2p.gossip");
+// a pattern with wildcards is matched with path.Match. When several rules
+// match, the longest pattern wins, and a literal pattern wins over a glob of
+// the same length.
+//
+// A matching rule takes precedence over the level of the logger, except for
+// the loggers whose level was set with Logger.Level or WithLevelVar, or with
+// SetLogLevel for those of a Factory, and for Disabled loggers. Changes take
+// effect immediately on every named logger. An empty spec removes all rules.
+func SetNamedLevels(spec string) error {
+	var rules []namedLevelRule
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		pattern, levelStr, ok := strings.Cut(rule, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return fmt.Errorf("invalid named level rule %q: expected name=level", rule)
+		}
+		lvl, err := ToLevel(strings.TrimSpace(levelStr))
+		if err != nil {
+			return fmt.Errorf("invalid named level rule %q: %w", rule, err)
+		}
+		glob := strings.ContainsAny(pattern, "*?[")
+		if glob {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid named level rule %q: %w", rule, err)
+			}
+		}
+		rules = append(rules, namedLevelRule{pattern: pattern, glob: glob, level: lvl})
+	}
+
+	namedLevels.Store(&namedLevelRules{
+		spec:  spec,
+		rules: rules,
+		gen:   namedLevelGen.Add(1),
+	})
+	updateLevelOverrides()
+	return nil
+}
+
+// NamedLevels returns the spec last passed to SetNamedLevels.
+func NamedLevels() string {
+	if rules := namedLevels.Load(); rules != nil {
+		return rules.spec
+	}
+	return ""
+}
+
+// LookupNamedLevel returns the level the rules set with SetNamedLevels assign
+// to the logger name, and whether any rule matches.
+func LookupNamedLevel(name string) (Level, bool) {
+	rules := namedLevels.Load()
+	if rules == nil {
+		return NoLevel, false
+	}
+	return rules.lookup(name)
+}
+
+func (r *namedLevelRules) lookup(name string) (Level, bool) {
+	var best *namedLevelRule
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if !rule.matches(name) {
+			continue
+		}
+		if best == nil || len(rule.pattern) > len(best.pattern) ||
+			(len(rule.pattern) == len(best.pattern) && (best.glob || !rule.glob)) {
+			best = rule
+		}
+	}
+	if best == nil {
+		return NoLevel, false
+	}
+	return best.level, true
+}
+
+func (r *namedLevelRule) matches(name string) bool {
+	if r.glob {
+		ok, _ := path.Match(r.pattern, name)
+		return ok
+	}
+	return name == r.pattern ||
+		(strings.HasPrefix(name, r.pattern) && name[len(r.pattern)] == '.')
+}
+
+// loggerName is the name of a named logger, shared with its children. It
+// caches the level resolved from the named level rules.
+type loggerName struct {
+	name string
+
+	// follow is the name whose rules apply when none matches name, such as
+	// the chain logger of an index logger.
+	follow *loggerName
+
+	// explicit is set once the level of the logger was set explicitly,
+	// which the rules no longer override.
+	explicit atomic.Bool
+
+	// cached packs the generation of the rules it was resolved from (upper
+	// bits), whether a rule matched (bit 8) and the level (lower byte).
+	cached atomic.Uint64
+}
+
+const namedLevelMatched = 1 << 8
+
+// explicitName returns a name like n whose level was set explicitly, or nil if
+// n is nil.
+func explicitName(n *loggerName) *loggerName {
+	if n == nil {
+		return nil
+	}
+	e := &loggerName{name: n.name}
+	e.explicit.Store(true)
+	return e
+}
+
+// level returns the level assigned to the name by the current rules, unless
+// the level of the logger was set explicitly.
+func (n *loggerName) level() (Level, bool) {
+	rules := namedLevels.Load()
+	if rules == nil || n.explicit.Load() {
+		return NoLevel, false
+	}
+	c := n.cached.Load()
+	if c>>16 != rules.gen {
+		lvl, ok := rules.lookup(n.name)
+		c = rules.gen<<16 | uint64(uint8(lvl))
+		if ok {
+			c |= namedLevelMatched
+		}
+		n.cached.Store(c)
+	}
+	if c&namedLevelMatched == 0 {
+		if n.follow != nil {
+			return n.follow.level()
+		}
+		return NoLevel, false
+	}
+	return Level(int8(uint8(c))), true
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNamedLevels(t *testing.T) {
+	defer SetNamedLevels("")
+
+	var buf bytes.Buffer
+	root := NewWriter(&buf).Level(InfoLevel)
+	p2p := root.Named("p2p")
+	gossip := p2p.Named("gossip")
+	chain := root.Named("chain").Named("C")
+
+	gossip.Info("hello")
+	if want := `{"level":"info","logger":"p2p.gossip","message":"hello"}` + "\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	if err := SetNamedLevels("p2p=warn,p2p.*=debug,chain.C=error"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		l     Logger
+		level Level
+		want  bool
+	}{
+		{p2p, InfoLevel, false},
+		{p2p, WarnLevel, true},
+		{gossip, DebugLevel, true},
+		{gossip.New("peer", 1), DebugLevel, true},
+		{chain, WarnLevel, false},
+		{root, InfoLevel, true},
+	}
+	for i, tt := range tests {
+		if got := tt.l.WithLevel(tt.level).Enabled(); got != tt.want {
+			t.Errorf("%d: enabled = %v, want %v", i, got, tt.want)
+		}
+	}
+
+	if err := SetNamedLevels("chain=trace"); err != nil {
+		t.Fatal(err)
+	}
+	if !chain.WithLevel(DebugLevel).Enabled() || p2p.WithLevel(DebugLevel).Enabled() {
+		t.Error("named levels were not updated at runtime")
+	}
+
+	if err := SetNamedLevels("p2p"); err == nil {
+		t.Error("expected an error for a rule without a level")
+	}
+}
+
+func TestNamedLevelsExplicit(t *testing.T) {
+	defer SetNamedLevels("")
+	if err := SetNamedLevels("p2p=debug"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	p2p := NewWriter(&buf).Level(InfoLevel).Named("p2p")
+	if got := p2p.GetLevel(); got != DebugLevel {
+		t.Errorf("GetLevel = %v, want the level of the rule", got)
+	}
+
+	tests := []struct {
+		l     Logger
+		level Level
+		want  bool
+	}{
+		{p2p.Level(ErrorLevel), InfoLevel, false},
+		{p2p.Level(Disabled), ErrorLevel, false},
+		{WithLevelVar(p2p, NewLevelVar(WarnLevel)), InfoLevel, false},
+		{WithLevelVar(p2p, NewLevelVar(Disabled)), ErrorLevel, false},
+		{p2p.Level(ErrorLevel), ErrorLevel, true},
+	}
+	for i, tt := range tests {
+		if got := tt.l.WithLevel(tt.level).Enabled(); got != tt.want {
+			t.Errorf("%d: enabled = %v, want %v", i, got, tt.want)
+		}
+	}
+	if got := p2p.Level(ErrorLevel).GetLevel(); got != ErrorLevel {
+		t.Errorf("GetLevel = %v, want the level set on the logger", got)
+	}
+
+	// A rule does not enable a Disabled logger, even with an inherited level.
+	v := NewLevelVar(InfoLevel)
+	shared := WithLevelVar(NewWriter(&buf), v).Named("p2p")
+	v.Set(Disabled)
+	if shared.WithLevel(ErrorLevel).Enabled() {
+		t.Error("the rule enabled a Disabled logger")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// onceSetSize bounds the number of keys remembered by Once. Past it, the
+// oldest keys are forgotten, and their events may be logged again.
+const onceSetSize = 4096
+
+// Once logs an event once per key, as returned by Logger.Once, for
+// deprecation notices and configuration warnings:
+//
+//	l.Once("legacy-config").Warn("config key is deprecated", "key", k)
+//
+// The keys are shared by all loggers, and kept in a bounded set, so a key
+// may be logged again once many others were. Events dropped by the logger
+// level do not count, and no event is created for the others already logged.
+type Once struct {
+	l      Logger
+	key    string
+	window time.Duration
+}
+
+// onceSet remembers when the keys of Once were logged.
+type onceSet struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+	// ring holds the keys of seen in insertion order, from next.
+	ring []string
+	next int
+}
+
+var onces = &onceSet{seen: make(map[string]time.Time)}
+
+// allow reports whether key was not logged, or was logged window ago or
+// more, and records it as logged if so.
+func (s *onceSet) allow(key string, window time.Duration) bool {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.seen[key]; ok {
+		if window <= 0 || now.Sub(last) < window {
+			return false
+		}
+		s.seen[key] = now
+		return true
+	}
+	if len(s.ring) < onceSetSize {
+		s.ring = append(s.ring, key)
+	} else {
+		delete(s.seen, s.ring[s.next])
+		s.ring[s.next] = key
+		s.next = (s.next + 1) % onceSetSize
+	}
+	s.seen[key] = now
+	return true
+}
+
+// Once returns a logger of the events of key, or of their message if key is
+// empty, logging them once.
+func (l *logger) Once(key string) Once {
+	return Once{l: l, key: key}
+}
+
+// For returns a copy of o logging the event again once window has elapsed
+// since it was last logged.
+func (o Once) For(window time.Duration) Once {
+	o.window = window
+	return o
+}
+
+func (o Once) Trace(msg string, ctx ...interface{}) {
+	o.log(TraceLevel, msg, ctx)
+}
+
+func (o Once) Debug(msg string, ctx ...interface{}) {
+	o.log(DebugLevel, msg, ctx)
+}
+
+func (o Once) Info(msg string, ctx ...interface{}) {
+	o.log(InfoLevel, msg, ctx)
+}
+
+func (o Once) Warn(msg string, ctx ...interface{}) {
+	o.log(WarnLevel, msg, ctx)
+}
+
+func (o Once) Error(msg string, ctx ...interface{}) {
+	o.log(ErrorLevel, msg, ctx)
+}
+
+// Log logs at level, unless the key was already logged.
+func (o Once) Log(level Level, msg string, ctx ...interface{}) {
+	o.log(level, msg, ctx)
+}
+
+func (o Once) log(level Level, msg string, ctx []interface{}) {
+	if !levelEnabled(o.l, level) {
+		return
+	}
+	key := o.key
+	if key == "" {
+		key = msg
+	}
+	if !onces.allow(key, o.window) {
+		return
+	}
+	applyContext(o.l.WithLevel(level), ctx).CallerSkipFrame(1).Msg(msg)
+}
+
+// WarnOnce logs at warn level with the root logger, once per message.
+func WarnOnce(msg string, ctx ...interface{}) {
+	Once{l: rootLogger()}.log(WarnLevel, msg, ctx)
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOnce(t *testing.T) {
+	onces = &onceSet{seen: make(map[string]time.Time)}
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf)
+	for i := 0; i < 3; i++ {
+		l.Once("").Warn("deprecated", "i", i)
+		l.Once("k").Info("keyed", "i", i)
+		l.Once("window").For(time.Nanosecond).Info("window", "i", i)
+		time.Sleep(time.Millisecond)
+	}
+
+	want := `{"level":"warn","i":0,"message":"deprecated"}` + "\n" +
+		`{"level":"info","i":0,"message":"keyed"}` + "\n" +
+		`{"level":"info","i":0,"message":"window"}` + "\n" +
+		`{"level":"info","i":1,"message":"window"}` + "\n" +
+		`{"level":"info","i":2,"message":"window"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOnceSetBounded(t *testing.T) {
+	s := &onceSet{seen: make(map[string]time.Time)}
+	for i := 0; i <= onceSetSize; i++ {
+		if !s.allow(strconv.Itoa(i), 0) {
+			t.Fatalf("key %d not allowed", i)
+		}
+	}
+	if len(s.seen) != onceSetSize {
+		t.Errorf("set holds %d keys, want %d", len(s.seen), onceSetSize)
+	}
+	if !s.allow("0", 0) {
+		t.Error("the oldest key was not forgotten")
+	}
+	if s.allow(strconv.Itoa(onceSetSize), 0) {
+		t.Error("the newest key was forgotten")
+	}
+}
+
+func TestOnceAllocs(t *testing.T) {
+	onces = &onceSet{seen: make(map[string]time.Time)}
+	l := NewWriter(io.Discard)
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Once("k").Info("logged once")
+	})
+	if allocs != 0 {
+		t.Errorf("events already logged allocate %v times", allocs)
+	}
+}
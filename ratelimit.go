@@ -0,0 +1,267 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a lines-per-second and bytes-per-second budget. A zero rate
+// means no limit on that dimension.
+type RateLimit struct {
+	// Lines is the number of lines allowed per second.
+	Lines float64
+	// Bytes is the number of bytes allowed per second.
+	Bytes float64
+	// Burst is the number of seconds worth of budget that can be spent at
+	// once. Defaults to 1.
+	Burst float64
+}
+
+// RateLimitedWriter drops log lines exceeding a per-level budget, enforced with
+// token buckets on both line count and byte volume. Unlike samplers, it sees
+// the encoded line, so a loop logging large payloads is throttled on size too.
+//
+// Dropped lines are reported at most once per SummaryInterval with a
+// synthetic warning line such as "dropped 8231 debug lines in last 10s", the
+// window starting at the first line dropped since the previous summary. The
+// summary is written by the first write after SummaryInterval, or by a timer
+// once SummaryInterval elapsed since the first drop if nothing is written,
+// so that a storm is reported even if logging stops. Close stops the timer.
+type RateLimitedWriter struct {
+	// Writer is the destination writer. If it implements LevelWriter, its
+	// WriteLevel is used instead of Write.
+	Writer io.Writer
+
+	// Limits holds the budget of each level. Levels without an entry use
+	// DefaultLimit.
+	Limits map[Level]RateLimit
+
+	// DefaultLimit is the budget of levels not listed in Limits. The zero
+	// value does not limit.
+	DefaultLimit RateLimit
+
+	// SummaryInterval is the minimum time between two dropped-line summaries.
+	// Defaults to 10 seconds.
+	SummaryInterval time.Duration
+
+	mu          sync.Mutex
+	buckets     map[Level]*tokenBucket
+	dropped     map[Level]uint64
+	lastSummary time.Time
+	firstDrop   time.Time
+	timer       *time.Timer
+}
+
+// NewRateLimitedWriter creates a RateLimitedWriter applying limit to every
+// level.
+func NewRateLimitedWriter(w io.Writer, limit RateLimit) *RateLimitedWriter {
+	return &RateLimitedWriter{
+		Writer:       w,
+		DefaultLimit: limit,
+	}
+}
+
+type tokenBucket struct {
+	lines, bytes float64
+	last         time.Time
+}
+
+// Write implements the io.Writer interface. Lines written without a level are
+// accounted as NoLevel.
+func (w *RateLimitedWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface. Dropped lines are reported
+// as written so that callers do not treat them as errors.
+func (w *RateLimitedWriter) WriteLevel(l Level, p []byte) (n int, err error) {
+	now := TimestampFunc()
+
+	w.mu.Lock()
+	allowed := w.allow(l, len(p), now)
+	if !allowed {
+		if w.dropped == nil {
+			w.dropped = make(map[Level]uint64)
+		}
+		if len(w.dropped) == 0 {
+			w.firstDrop = now
+		}
+		w.dropped[l]++
+		if w.timer == nil {
+			w.timer = time.AfterFunc(w.summaryInterval(), w.flushTimer)
+		}
+	}
+	summary := w.summaryDue(now)
+	w.mu.Unlock()
+
+	if summary {
+		w.Flush()
+	}
+	if !allowed {
+		return len(p), nil
+	}
+	return w.write(l, p)
+}
+
+func (w *RateLimitedWriter) write(l Level, p []byte) (int, error) {
+	if lw, ok := w.Writer.(LevelWriter); ok {
+		return lw.WriteLevel(l, p)
+	}
+	return w.Writer.Write(p)
+}
+
+// allow expects lock to be held.
+func (w *RateLimitedWriter) allow(l Level, size int, now time.Time) bool {
+	limit, ok := w.Limits[l]
+	if !ok {
+		limit = w.DefaultLimit
+	}
+	if limit.Lines <= 0 && limit.Bytes <= 0 {
+		return true
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	maxLines, maxBytes := limit.Lines*burst, limit.Bytes*burst
+
+	if w.buckets == nil {
+		w.buckets = make(map[Level]*tokenBucket)
+	}
+	b := w.buckets[l]
+	if b == nil {
+		b = &tokenBucket{lines: maxLines, bytes: maxBytes, last: now}
+		w.buckets[l] = b
+	}
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.lines = min(maxLines, b.lines+elapsed*limit.Lines)
+		b.bytes = min(maxBytes, b.bytes+elapsed*limit.Bytes)
+		b.last = now
+	}
+
+	// A line larger than the whole byte budget passes when the bucket is
+	// full, otherwise it could never be written.
+	need := min(float64(size), maxBytes)
+	if (limit.Lines > 0 && b.lines < 1) || (limit.Bytes > 0 && b.bytes < need) {
+		return false
+	}
+	if limit.Lines > 0 {
+		b.lines--
+	}
+	if limit.Bytes > 0 {
+		b.bytes -= need
+	}
+	return true
+}
+
+func (w *RateLimitedWriter) summaryInterval() time.Duration {
+	if w.SummaryInterval <= 0 {
+		return 10 * time.Second
+	}
+	return w.SummaryInterval
+}
+
+// summaryDue expects lock to be held.
+func (w *RateLimitedWriter) summaryDue(now time.Time) bool {
+	if w.lastSummary.IsZero() {
+		w.lastSummary = now
+		return false
+	}
+	return len(w.dropped) > 0 && now.Sub(w.lastSummary) >= w.summaryInterval()
+}
+
+// flushTimer writes the summaries when no write did within SummaryInterval
+// of the first drop.
+func (w *RateLimitedWriter) flushTimer() {
+	if err := w.Flush(); err != nil {
+		reportWriterError(err)
+	}
+}
+
+// Dropped returns the number of lines dropped per level since the last
+// summary.
+func (w *RateLimitedWriter) Dropped() map[Level]uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dropped := make(map[Level]uint64, len(w.dropped))
+	for l, n := range w.dropped {
+		dropped[l] = n
+	}
+	return dropped
+}
+
+// Flush writes the dropped-line summaries immediately, regardless of
+// SummaryInterval. Summaries bypass the rate limits.
+func (w *RateLimitedWriter) Flush() error {
+	now := TimestampFunc()
+
+	w.mu.Lock()
+	dropped := w.dropped
+	since := w.firstDrop
+	w.dropped = nil
+	w.lastSummary = now
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+
+	window := now.Sub(since).Round(time.Second)
+	var firstErr error
+	for _, l := range []Level{NoLevel, TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel} {
+		if n := dropped[l]; n > 0 {
+			if err := w.writeSummary(l, n, window); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			delete(dropped, l)
+		}
+	}
+	// Custom numeric levels.
+	for l, n := range dropped {
+		if err := w.writeSummary(l, n, window); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (w *RateLimitedWriter) writeSummary(l Level, n uint64, window time.Duration) error {
+	name := l.String()
+	if l == NoLevel {
+		name = "unleveled"
+	}
+	msg := "dropped " + strconv.FormatUint(n, 10) + " " + name + " lines"
+	if window > 0 {
+		msg += " in last " + window.String()
+	}
+
+	e := newEvent(LevelWriterAdapter{io.Discard}, WarnLevel, false, nil, nil)
+	e.Str(LevelFieldName, LevelFieldMarshalFunc(WarnLevel)).
+		Timestamp().
+		Uint64("dropped", n).
+		Str("dropped_level", name).
+		Str(MessageFieldName, msg)
+	e.buf = enc.AppendLineBreak(enc.AppendEndMarker(e.buf))
+	_, err := w.write(WarnLevel, e.buf)
+	putEvent(e)
+	return err
+}
+
+// Close flushes the pending summaries and closes the underlying writer if it
+// is an io.Closer.
+func (w *RateLimitedWriter) Close() error {
+	err := w.Flush()
+	if closer, ok := w.Writer.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
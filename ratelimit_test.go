@@ -0,0 +1,173 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces TimestampFunc until the test ends.
+func fakeClock(t *testing.T) *time.Time {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	saved := TimestampFunc
+	TimestampFunc = func() time.Time { return now }
+	t.Cleanup(func() { TimestampFunc = saved })
+	return &now
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	type write struct {
+		advance time.Duration
+		size    int
+	}
+	tests := []struct {
+		name        string
+		limit       RateLimit
+		writes      []write
+		wantWritten int
+		wantDropped uint64
+	}{
+		{
+			name:        "line bucket",
+			limit:       RateLimit{Lines: 2},
+			writes:      []write{{0, 4}, {0, 4}, {0, 4}},
+			wantWritten: 2,
+			wantDropped: 1,
+		},
+		{
+			name:        "byte bucket",
+			limit:       RateLimit{Bytes: 10},
+			writes:      []write{{0, 4}, {0, 4}, {0, 4}},
+			wantWritten: 2,
+			wantDropped: 1,
+		},
+		{
+			name:        "burst refill",
+			limit:       RateLimit{Lines: 1, Burst: 2},
+			writes:      []write{{0, 4}, {0, 4}, {0, 4}, {time.Second, 4}, {0, 4}, {2 * time.Second, 4}, {0, 4}},
+			wantWritten: 5,
+			wantDropped: 2,
+		},
+		{
+			name:        "oversize line with full bucket",
+			limit:       RateLimit{Bytes: 4},
+			writes:      []write{{0, 10}, {0, 1}, {time.Second, 10}},
+			wantWritten: 2,
+			wantDropped: 1,
+		},
+		{
+			name:        "no limit",
+			writes:      []write{{0, 1 << 20}, {0, 1 << 20}},
+			wantWritten: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := fakeClock(t)
+			var out bytes.Buffer
+			w := NewRateLimitedWriter(&out, tt.limit)
+			w.SummaryInterval = time.Hour
+			defer w.Close()
+
+			written := 0
+			for _, wr := range tt.writes {
+				*now = now.Add(wr.advance)
+				line := append(bytes.Repeat([]byte("x"), wr.size-1), '\n')
+				before := out.Len()
+				n, err := w.WriteLevel(DebugLevel, line)
+				if err != nil || n != len(line) {
+					t.Fatalf("WriteLevel() = %d, %v", n, err)
+				}
+				if out.Len() > before {
+					written++
+				}
+			}
+			if written != tt.wantWritten {
+				t.Errorf("written %d lines, want %d", written, tt.wantWritten)
+			}
+			if got := w.Dropped()[DebugLevel]; got != tt.wantDropped {
+				t.Errorf("dropped %d lines, want %d", got, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestRateLimitedWriterSummary(t *testing.T) {
+	now := fakeClock(t)
+	var out bytes.Buffer
+	w := NewRateLimitedWriter(&out, RateLimit{Lines: 1})
+	w.SummaryInterval = 10 * time.Second
+	defer w.Close()
+
+	w.WriteLevel(DebugLevel, []byte("a\n"))
+	// The storm starts 6s after the first line.
+	*now = now.Add(6 * time.Second)
+	for i := 0; i < 4; i++ {
+		w.WriteLevel(DebugLevel, []byte("b\n"))
+	}
+	w.WriteLevel(InfoLevel, []byte("c\n"))
+	out.Reset()
+
+	// The summary is due 10s after the previous one, and covers the 4s since
+	// the first drop.
+	*now = now.Add(4 * time.Second)
+	w.WriteLevel(InfoLevel, []byte("d\n"))
+
+	lines := strings.SplitN(out.String(), "\n", 2)
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &summary); err != nil {
+		t.Fatalf("bad summary %q: %v", lines[0], err)
+	}
+	if summary["message"] != "dropped 3 debug lines in last 4s" ||
+		summary["dropped"] != 3.0 || summary["dropped_level"] != "debug" || summary["level"] != "warn" {
+		t.Errorf("bad summary: %v", summary)
+	}
+	if lines[1] != "d\n" {
+		t.Errorf("line after the summary = %q", lines[1])
+	}
+	if len(w.Dropped()) != 0 {
+		t.Errorf("dropped counts not reset: %v", w.Dropped())
+	}
+}
+
+func TestRateLimitedWriterSummaryTimer(t *testing.T) {
+	var out syncBuffer
+	w := NewRateLimitedWriter(&out, RateLimit{Lines: 1})
+	w.SummaryInterval = 10 * time.Millisecond
+	defer w.Close()
+
+	w.WriteLevel(DebugLevel, []byte("a\n"))
+	w.WriteLevel(DebugLevel, []byte("b\n"))
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "dropped 1 debug lines") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary written after the storm: %q", out.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,258 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// RingWriter is a flight recorder: it keeps the most recent log lines of every
+// level in memory, regardless of the level configured for the other outputs,
+// so that the context leading up to a crash is not lost.
+//
+// It is meant to be combined with the regular outputs through MultiLevelWriter,
+// with the logger level set to the lowest level to record and the other
+// outputs wrapped in a FilteredLevelWriter:
+//
+//	ring := log.NewRingWriter(5000, 4<<20)
+//	ring.DumpPath = "/var/log/node/crash.log"
+//	w := log.MultiLevelWriter(ring, &log.FilteredLevelWriter{Writer: file, Level: log.InfoLevel})
+//	l := log.NewWriter(w).Level(log.TraceLevel)
+//
+// When a Fatal or Panic line passes through, the buffer (including that line)
+// is dumped to DumpWriter and/or DumpPath.
+type RingWriter struct {
+	// MaxEntries is the maximum number of lines kept. Zero means no limit on
+	// the number of lines.
+	MaxEntries int
+
+	// MaxBytes is the maximum number of bytes kept. Zero means no limit on
+	// the size. If both limits are zero, 1000 lines are kept.
+	MaxBytes int
+
+	// DumpWriter, if set, receives the buffer when a Fatal or Panic line is
+	// written.
+	DumpWriter io.Writer
+
+	// DumpPath, if set, is the file the buffer is appended to when a Fatal or
+	// Panic line is written.
+	DumpPath string
+
+	mu      sync.Mutex
+	entries []ringEntry
+	head    int // index of the oldest entry
+	count   int
+	size    int
+}
+
+type ringEntry struct {
+	level Level
+	p     []byte
+}
+
+// NewRingWriter creates a RingWriter keeping at most maxEntries lines and
+// maxBytes bytes. A zero limit is ignored.
+func NewRingWriter(maxEntries, maxBytes int) *RingWriter {
+	return &RingWriter{
+		MaxEntries: maxEntries,
+		MaxBytes:   maxBytes,
+	}
+}
+
+// Write implements the io.Writer interface. Lines written without a level are
+// recorded as NoLevel.
+func (w *RingWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(NoLevel, p)
+}
+
+// WriteLevel implements the LevelWriter interface.
+func (w *RingWriter) WriteLevel(l Level, p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.push(l, p)
+	if l == FatalLevel || l == PanicLevel {
+		if err := w.dump(); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// push expects lock to be held.
+func (w *RingWriter) push(l Level, p []byte) {
+	maxEntries := w.MaxEntries
+	if maxEntries <= 0 && w.MaxBytes <= 0 {
+		maxEntries = 1000
+	}
+	if w.entries == nil {
+		capacity := maxEntries
+		if capacity <= 0 {
+			capacity = 64
+		}
+		w.entries = make([]ringEntry, 0, capacity)
+	}
+
+	// The event buffer is reused once the write returns, so keep a copy.
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	for w.count > 0 && ((maxEntries > 0 && w.count >= maxEntries) ||
+		(w.MaxBytes > 0 && w.size+len(line) > w.MaxBytes)) {
+		w.pop()
+	}
+
+	e := ringEntry{level: l, p: line}
+	if w.count < len(w.entries) {
+		w.entries[(w.head+w.count)%len(w.entries)] = e
+	} else if w.head == 0 {
+		w.entries = append(w.entries, e)
+	} else {
+		// Unroll the ring before growing it.
+		entries := make([]ringEntry, 0, 2*len(w.entries))
+		w.each(func(e ringEntry) { entries = append(entries, e) })
+		w.entries = append(entries, e)
+		w.head = 0
+	}
+	w.count++
+	w.size += len(line)
+}
+
+// pop drops the oldest entry and expects lock to be held.
+func (w *RingWriter) pop() {
+	e := &w.entries[w.head]
+	w.size -= len(e.p)
+	e.p = nil
+	w.head = (w.head + 1) % len(w.entries)
+	w.count--
+}
+
+// each calls fn on every entry from the oldest to the newest and expects lock
+// to be held.
+func (w *RingWriter) each(fn func(e ringEntry)) {
+	for i := 0; i < w.count; i++ {
+		fn(w.entries[(w.head+i)%len(w.entries)])
+	}
+}
+
+// dump expects lock to be held.
+func (w *RingWriter) dump() error {
+	var firstErr error
+	if w.DumpWriter != nil {
+		if err := w.writeTo(w.DumpWriter, NoLevel); err != nil {
+			firstErr = err
+		}
+	}
+	if w.DumpPath != "" {
+		f, err := os.OpenFile(w.DumpPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("ring writer: could not open dump file: %w", err)
+			}
+			return firstErr
+		}
+		err = w.writeTo(f, NoLevel)
+		if syncErr := f.Sync(); err == nil {
+			err = syncErr
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// writeTo writes the entries at minLevel or above (and those without a level)
+// to out. It expects lock to be held.
+func (w *RingWriter) writeTo(out io.Writer, minLevel Level) (err error) {
+	w.each(func(e ringEntry) {
+		if err != nil || !ringLevelEnabled(e.level, minLevel) {
+			return
+		}
+		_, err = out.Write(e.p)
+	})
+	return err
+}
+
+func ringLevelEnabled(l, minLevel Level) bool {
+	return minLevel == NoLevel || l == NoLevel || l >= minLevel
+}
+
+// Dump writes the buffered lines, oldest first, to out.
+func (w *RingWriter) Dump(out io.Writer) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writeTo(out, NoLevel)
+}
+
+// Snapshot returns a copy of the buffered lines, oldest first.
+func (w *RingWriter) Snapshot() [][]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	lines := make([][]byte, 0, w.count)
+	w.each(func(e ringEntry) {
+		line := make([]byte, len(e.p))
+		copy(line, e.p)
+		lines = append(lines, line)
+	})
+	return lines
+}
+
+// Len returns the number of buffered lines and their total size in bytes.
+func (w *RingWriter) Len() (entries, bytes int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.count, w.size
+}
+
+// Reset drops all the buffered lines.
+func (w *RingWriter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries = nil
+	w.head, w.count, w.size = 0, 0, 0
+}
+
+// ServeHTTP streams the buffered lines as JSON lines, oldest first. The
+// optional "level" query parameter restricts the output to lines at that level
+// or above.
+func (w *RingWriter) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	minLevel := NoLevel
+	if s := r.URL.Query().Get("level"); s != "" {
+		lvl, err := ParseLevel(s)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		minLevel = lvl
+	}
+
+	// Copy the lines out first so that a slow client does not block logging.
+	w.mu.Lock()
+	lines := make([][]byte, 0, w.count)
+	w.each(func(e ringEntry) {
+		if ringLevelEnabled(e.level, minLevel) {
+			lines = append(lines, e.p)
+		}
+	})
+	w.mu.Unlock()
+
+	rw.Header().Set("Content-Type", "application/x-ndjson")
+	for _, line := range lines {
+		if _, err := rw.Write(line); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRingWriterKeepsLastEntries(t *testing.T) {
+	ring := NewRingWriter(3, 0)
+	l := NewWriter(ring)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		l.DebugEvent().Msg(msg)
+	}
+
+	lines := ring.Snapshot()
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	for i, msg := range []string{"c", "d", "e"} {
+		if want := `"message":"` + msg + `"`; !bytes.Contains(lines[i], []byte(want)) {
+			t.Errorf("line %d = %q, want it to contain %s", i, lines[i], want)
+		}
+	}
+}
+
+func TestRingWriterMaxBytes(t *testing.T) {
+	ring := NewRingWriter(0, 64)
+	for i := 0; i < 10; i++ {
+		_, _ = ring.WriteLevel(InfoLevel, []byte("0123456789abcdef\n"))
+	}
+	entries, size := ring.Len()
+	if entries != 3 || size != 51 {
+		t.Errorf("Len() = %d, %d, want 3, 51", entries, size)
+	}
+}
+
+func TestRingWriterDumpOnPanic(t *testing.T) {
+	var dump bytes.Buffer
+	ring := NewRingWriter(10, 0)
+	ring.DumpWriter = &dump
+	l := NewWriter(ring)
+
+	l.DebugEvent().Msg("before")
+	func() {
+		defer func() { _ = recover() }()
+		l.PanicEvent().Msg("boom")
+	}()
+
+	got := dump.String()
+	if !strings.Contains(got, `"message":"before"`) || !strings.Contains(got, `"message":"boom"`) {
+		t.Errorf("dump = %q, want both lines", got)
+	}
+}
+
+func TestRingWriterServeHTTP(t *testing.T) {
+	ring := NewRingWriter(10, 0)
+	l := NewWriter(ring)
+	l.DebugEvent().Msg("debug")
+	l.WarnEvent().Msg("warn")
+
+	rec := httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest("GET", "/?level=warn", nil))
+
+	want := `{"level":"warn","message":"warn"}` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
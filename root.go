@@ -0,0 +1,236 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// rootProxy is the Logger returned by Root. It resolves the logger set with
+// SetDefault at each call, adding its own name and context fields, and
+// applying the derivations of the loggers derived from it with Ctx, Output,
+// Level, Sample, Hook and WithLevelVar.
+type rootProxy struct {
+	name   string
+	ctx    []interface{}
+	derive []func(Logger) Logger
+
+	cache atomic.Pointer[rootProxyCache]
+}
+
+// rootProxyCache is the logger a rootProxy resolved for a root logger.
+type rootProxyCache struct {
+	root *Logger
+	l    Logger
+}
+
+var rootProxySingleton = &rootProxy{}
+
+// resolve returns the current root logger with the name and context fields of
+// the proxy. The result is cached until SetDefault is called again.
+func (p *rootProxy) resolve() Logger {
+	root := defaultLogger.Load()
+	if p.name == "" && len(p.ctx) == 0 && len(p.derive) == 0 {
+		return *root
+	}
+	if c := p.cache.Load(); c != nil && c.root == root {
+		return c.l
+	}
+	l := *root
+	if p.name != "" {
+		l = l.Named(p.name)
+	}
+	if len(p.ctx) > 0 {
+		l = l.New(p.ctx...)
+	}
+	for _, derive := range p.derive {
+		l = derive(l)
+	}
+	p.cache.Store(&rootProxyCache{root: root, l: l})
+	return l
+}
+
+// resolveRoot returns the logger l currently resolves to if it was derived
+// from Root, or l itself.
+func resolveRoot(l Logger) Logger {
+	if p, ok := l.(*rootProxy); ok {
+		return p.resolve()
+	}
+	return l
+}
+
+// The logging methods build the event themselves rather than calling the
+// method of the resolved logger, so that the caller field skips as many
+// frames as it does for other loggers.
+
+func (p *rootProxy) Trace(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().TraceEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Debug(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().DebugEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Info(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().InfoEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Warn(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().WarnEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Error(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().ErrorEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Fatal(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().FatalEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Panic(msg string, ctx ...interface{}) {
+	applyContext(p.resolve().PanicEvent(), ctx).Msg(msg)
+}
+
+func (p *rootProxy) Crit(msg string, ctx ...interface{}) {
+	p.Fatal(msg, ctx...)
+}
+
+func (p *rootProxy) Verbo(msg string, ctx ...interface{}) {
+	p.Trace(msg, ctx...)
+}
+
+func (p *rootProxy) Log(level Level, msg string, ctx ...interface{}) {
+	applyContext(p.resolve().WithLevel(level), ctx).Msg(msg)
+}
+
+// Start starts a span whose logger follows SetDefault.
+func (p *rootProxy) Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	return startSpan(p, ctx, name, kv)
+}
+
+// With returns a Context based on the current root logger. The logger it
+// creates no longer follows SetDefault, as the fields of a Context are
+// encoded as they are added; use New to add fields to a logger that does.
+func (p *rootProxy) With() Context {
+	return p.resolve().With()
+}
+
+// New returns a logger following SetDefault with the fields of p and ctx.
+func (p *rootProxy) New(ctx ...interface{}) Logger {
+	if len(ctx) == 0 {
+		return p
+	}
+	fields := make([]interface{}, 0, len(p.ctx)+len(ctx))
+	fields = append(fields, p.ctx...)
+	return &rootProxy{name: p.name, ctx: append(fields, ctx...), derive: p.derive}
+}
+
+// Named returns a logger following SetDefault, named as Logger.Named does.
+func (p *rootProxy) Named(name string) Logger {
+	if p.name != "" {
+		name = p.name + "." + name
+	}
+	return &rootProxy{name: name, ctx: p.ctx, derive: p.derive}
+}
+
+// with returns a logger following SetDefault, derived from the root logger
+// as p is, then with derive.
+func (p *rootProxy) with(derive func(Logger) Logger) Logger {
+	d := make([]func(Logger) Logger, 0, len(p.derive)+1)
+	d = append(d, p.derive...)
+	return &rootProxy{name: p.name, ctx: p.ctx, derive: append(d, derive)}
+}
+
+// Ctx, Output, Level, Sample and Hook return loggers following SetDefault.
+
+func (p *rootProxy) Ctx(ctx context.Context) Logger {
+	return p.with(func(l Logger) Logger { return l.Ctx(ctx) })
+}
+
+func (p *rootProxy) Output(w io.Writer) Logger {
+	return p.with(func(l Logger) Logger { return l.Output(w) })
+}
+
+func (p *rootProxy) Level(lvl Level) Logger {
+	return p.with(func(l Logger) Logger { return l.Level(lvl) })
+}
+
+func (p *rootProxy) Sample(s Sampler) Logger {
+	return p.with(func(l Logger) Logger { return l.Sample(s) })
+}
+
+func (p *rootProxy) Hook(hooks ...Hook) Logger {
+	return p.with(func(l Logger) Logger { return l.Hook(hooks...) })
+}
+
+// Every, FirstN and Once return loggers following SetDefault.
+
+func (p *rootProxy) Every(interval time.Duration) Throttle {
+	return Throttle{l: p, every: interval}
+}
+
+func (p *rootProxy) FirstN(n int) Throttle {
+	return Throttle{l: p, firstN: true, n: n}
+}
+
+func (p *rootProxy) Once(key string) Once {
+	return Once{l: p, key: key}
+}
+
+func (p *rootProxy) GetLevel() Level {
+	return p.resolve().GetLevel()
+}
+
+func (p *rootProxy) Enabled(ctx context.Context, level slog.Level) bool {
+	return p.resolve().Enabled(ctx, level)
+}
+
+func (p *rootProxy) TraceEvent() *Event       { return p.resolve().TraceEvent() }
+func (p *rootProxy) DebugEvent() *Event       { return p.resolve().DebugEvent() }
+func (p *rootProxy) InfoEvent() *Event        { return p.resolve().InfoEvent() }
+func (p *rootProxy) WarnEvent() *Event        { return p.resolve().WarnEvent() }
+func (p *rootProxy) ErrorEvent() *Event       { return p.resolve().ErrorEvent() }
+func (p *rootProxy) FatalEvent() *Event       { return p.resolve().FatalEvent() }
+func (p *rootProxy) PanicEvent() *Event       { return p.resolve().PanicEvent() }
+func (p *rootProxy) Err(err error) *Event     { return p.resolve().Err(err) }
+func (p *rootProxy) LogEvent() *Event         { return p.resolve().LogEvent() }
+func (p *rootProxy) WithLevel(l Level) *Event { return p.resolve().WithLevel(l) }
+
+func (p *rootProxy) Print(v ...interface{}) {
+	if e := p.resolve().DebugEvent(); e.Enabled() {
+		e.CallerSkipFrame(1).Msg(fmt.Sprint(v...))
+	}
+}
+
+func (p *rootProxy) Printf(format string, v ...interface{}) {
+	if e := p.resolve().DebugEvent(); e.Enabled() {
+		e.CallerSkipFrame(1).Msg(fmt.Sprintf(format, v...))
+	}
+}
+
+func (p *rootProxy) Write(b []byte) (n int, err error) {
+	n = len(b)
+	if n > 0 && b[n-1] == '\n' {
+		b = b[:n-1]
+	}
+	p.resolve().LogEvent().CallerSkipFrame(1).Msg(string(b))
+	return
+}
+
+func (p *rootProxy) SetLogLevel(level string) error {
+	return p.resolve().SetLogLevel(level)
+}
+
+func (p *rootProxy) RecoverAndPanic(fn func()) {
+	p.resolve().RecoverAndPanic(fn)
+}
+
+func (p *rootProxy) IsZero() bool {
+	return p.resolve().IsZero()
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestRootFollowsSetDefault(t *testing.T) {
+	saved := rootLogger()
+	defer SetDefault(saved)
+
+	// Obtained before the application configures logging, as in init.
+	l := Root().New("module", "p2p").Named("gossip")
+
+	var first, second bytes.Buffer
+	SetDefault(NewWriter(&first))
+	l.Info("one", "n", 1)
+	SetDefault(NewWriter(&second).With().Str("node", "a").Logger())
+	l.Info("two")
+	Info("three")
+
+	if want := `{"level":"info","logger":"gossip","module":"p2p","n":1,"message":"one"}` + "\n"; first.String() != want {
+		t.Errorf("got %q, want %q", first.String(), want)
+	}
+	want := `{"level":"info","logger":"gossip","node":"a","module":"p2p","message":"two"}` + "\n" +
+		`{"level":"info","node":"a","message":"three"}` + "\n"
+	if second.String() != want {
+		t.Errorf("got %q, want %q", second.String(), want)
+	}
+
+	// Setting Root as the default must not make it resolve to itself.
+	SetDefault(Root())
+	Root().Info("four")
+	if !bytes.HasSuffix(second.Bytes(), []byte(`"message":"four"}`+"\n")) {
+		t.Errorf("got %q", second.String())
+	}
+}
+
+func TestRootDerivedLoggersFollowSetDefault(t *testing.T) {
+	saved := rootLogger()
+	defer SetDefault(saved)
+
+	var hooked int
+	v := NewLevelVar(InfoLevel)
+	loggers := []Logger{
+		Root().Level(DebugLevel),
+		Root().Ctx(context.Background()),
+		Root().Sample(&BasicSampler{N: 1}),
+		Root().Hook(HookFunc(func(*Event, Level, string) { hooked++ })),
+		WithLevelVar(Root(), v),
+		Root().New("k", "v").Level(DebugLevel).Named("n"),
+	}
+
+	var first, second bytes.Buffer
+	for _, buf := range []*bytes.Buffer{&first, &second} {
+		SetDefault(NewWriter(buf))
+		for _, l := range loggers {
+			l.Info("msg")
+		}
+		if got := bytes.Count(buf.Bytes(), []byte("\n")); got != len(loggers) {
+			t.Errorf("got %d lines, want %d: %q", got, len(loggers), buf.String())
+		}
+	}
+	if hooked != 2 {
+		t.Errorf("hook ran %d times, want 2", hooked)
+	}
+
+	second.Reset()
+	loggers[len(loggers)-1].Debug("debug")
+	v.Set(WarnLevel)
+	loggers[4].Info("dropped")
+	want := `{"level":"debug","logger":"n","k":"v","message":"debug"}` + "\n"
+	if second.String() != want {
+		t.Errorf("got %q, want %q", second.String(), want)
+	}
+
+	var out bytes.Buffer
+	Root().Output(&out).Info("output")
+	if out.Len() == 0 {
+		t.Error("Output did not write to its writer")
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"math/big"
 	"os"
 	"reflect"
-	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -180,7 +179,23 @@ func (l *slogLogger) Error(msg string, ctx ...interface{}) {
 
 func (l *slogLogger) Crit(msg string, ctx ...interface{}) {
 	l.Write(slogLevelCrit, msg, ctx...)
-	os.Exit(1)
+	syncHandler(l.inner.Handler())
+	fatalExit(nil, nil)
+}
+
+// syncHandler syncs the output of h if it is a handler of this package
+// writing to a writer with a Sync method.
+func syncHandler(h slog.Handler) {
+	switch h := h.(type) {
+	case *TerminalHandler:
+		_ = syncOutput(h.wr)
+	case *GlogHandler:
+		syncHandler(h.origin)
+	case *slogHandler:
+		if l := h.logger(); l != nil {
+			_ = syncOutput(l.w)
+		}
+	}
 }
 
 func (l *slogLogger) Handler() slog.Handler {
@@ -269,147 +284,211 @@ func LevelString(l slog.Level) string {
 	}
 }
 
-// GlogHandler is a log handler that mimics glog behavior.
+// GlogHandler is a log handler that mimics glog behavior. It filters records
+// by a global verbosity, raised for the call sites matching the vmodule rules,
+// and can attach a stack trace to the records logged from one source line.
+//
+// The handlers returned by WithAttrs and WithGroup start with the settings of
+// their parent, which can then be changed independently.
 type GlogHandler struct {
-	origin       slog.Handler
-	verbosity    slog.Level
-	vmodule      map[string]slog.Level
-	patternCache map[string]*regexp.Regexp // cached compiled patterns
-	mu           sync.RWMutex
+	origin slog.Handler
+	state  *glogState
+}
+
+// glogState holds the settings of a GlogHandler and of the handlers derived
+// from it.
+type glogState struct {
+	verbosity atomic.Int64
+	rules     atomic.Pointer[glogRules]
+
+	// minLevel is the lowest of the verbosity and the vmodule rule levels,
+	// used by Enabled.
+	minLevel atomic.Int64
+
+	mu sync.Mutex // serializes the setters
+}
+
+// glogRules is an immutable set of vmodule rules and backtrace location. The
+// level of each program counter is resolved once per set.
+type glogRules struct {
+	vmodule []vmoduleRule
+
+	backtraceFile string
+	backtraceLine int
+
+	sites sync.Map // pc -> glogSite
+}
+
+// glogSite is the resolved settings of a program counter.
+type glogSite struct {
+	level     slog.Level
+	matched   bool
+	backtrace bool
 }
 
 // NewGlogHandler creates a GlogHandler wrapping the given handler.
 func NewGlogHandler(h slog.Handler) *GlogHandler {
-	return &GlogHandler{
-		origin:       h,
-		verbosity:    slogLevelInfo,
-		vmodule:      make(map[string]slog.Level),
-		patternCache: make(map[string]*regexp.Regexp),
-	}
+	s := &glogState{}
+	s.verbosity.Store(int64(slogLevelInfo))
+	s.minLevel.Store(int64(slogLevelInfo))
+	return &GlogHandler{origin: h, state: s}
 }
 
 // Verbosity sets the global verbosity level.
 func (h *GlogHandler) Verbosity(level slog.Level) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	h.verbosity = level
-}
-
-// Vmodule sets per-module verbosity patterns.
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verbosity.Store(int64(level))
+	s.updateMinLevel()
+}
+
+// Vmodule sets per-module verbosity patterns, as a comma-separated list of
+// pattern=level rules such as "processor.go=trace,luxfi/node/p2p=debug". A
+// rule matches a call site if its pattern matches the file path or the
+// package qualified function name of the code that logged the record. The
+// first matching rule applies. Malformed rules are skipped.
 func (h *GlogHandler) Vmodule(pattern string) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	rules, err := parseVmodule(pattern, true)
+	if err != nil {
+		return err
+	}
 
-	h.vmodule = make(map[string]slog.Level)
-	h.patternCache = make(map[string]*regexp.Regexp)
-	if pattern == "" {
-		return nil
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := &glogRules{vmodule: rules}
+	if old := s.rules.Load(); old != nil {
+		next.backtraceFile, next.backtraceLine = old.backtraceFile, old.backtraceLine
 	}
+	s.rules.Store(next)
+	s.updateMinLevel()
+	return nil
+}
 
-	for _, rule := range strings.Split(pattern, ",") {
-		parts := strings.Split(rule, "=")
-		if len(parts) != 2 {
-			continue
-		}
-		module := strings.TrimSpace(parts[0])
-		levelStr := strings.TrimSpace(parts[1])
-		level, err := LvlFromString(levelStr)
-		if err != nil {
-			var lvl int
-			if _, parseErr := fmt.Sscanf(levelStr, "%d", &lvl); parseErr == nil {
-				level = FromLegacyLevel(lvl)
-			} else {
-				continue
-			}
+// BacktraceAt attaches a stack trace to the records logged from location,
+// given as "file.go:123", when they pass the verbosity checks. The file is
+// matched against the end of the path of the source file. An empty location
+// turns it off.
+func (h *GlogHandler) BacktraceAt(location string) error {
+	var (
+		file string
+		line int
+	)
+	if location != "" {
+		var (
+			lineStr string
+			ok      bool
+			err     error
+		)
+		file, lineStr, ok = strings.Cut(location, ":")
+		if ok {
+			line, err = strconv.Atoi(lineStr)
 		}
-		h.vmodule[module] = level
-		// Precompile pattern regex
-		if re, err := compilePattern(module); err == nil {
-			h.patternCache[module] = re
+		if !ok || err != nil || line <= 0 || !strings.HasSuffix(file, ".go") {
+			return fmt.Errorf("invalid backtrace location %q: expected file.go:line", location)
 		}
 	}
+
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := &glogRules{backtraceFile: file, backtraceLine: line}
+	if old := s.rules.Load(); old != nil {
+		next.vmodule = old.vmodule
+	}
+	s.rules.Store(next)
 	return nil
 }
 
-func (h *GlogHandler) Handle(ctx context.Context, r slog.Record) error {
-	h.mu.RLock()
-	verbosity := h.verbosity
-	vmodule := h.vmodule
-	patternCache := h.patternCache
-	h.mu.RUnlock()
-
-	if len(vmodule) > 0 {
-		_, file, _, ok := runtime.Caller(6)
-		if ok {
-			for pattern, level := range vmodule {
-				re := patternCache[pattern]
-				if re != nil && re.MatchString(file) && r.Level >= level {
-					return h.origin.Handle(ctx, r)
-				}
-			}
+// clone returns a copy of the settings. The rules are immutable, so they are
+// shared along with the sites resolved for them.
+func (s *glogState) clone() *glogState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := &glogState{}
+	c.verbosity.Store(s.verbosity.Load())
+	c.rules.Store(s.rules.Load())
+	c.minLevel.Store(s.minLevel.Load())
+	return c
+}
+
+// updateMinLevel recomputes minLevel. s.mu must be held.
+func (s *glogState) updateMinLevel() {
+	minLevel := slog.Level(s.verbosity.Load())
+	if rules := s.rules.Load(); rules != nil {
+		for _, rule := range rules.vmodule {
+			minLevel = min(minLevel, rule.level)
 		}
 	}
+	s.minLevel.Store(int64(minLevel))
+}
 
-	if r.Level >= verbosity {
+// site returns the settings of pc.
+func (r *glogRules) site(pc uintptr) glogSite {
+	if site, ok := r.sites.Load(pc); ok {
+		return site.(glogSite)
+	}
+	cs := lookupCallSite(pc)
+	var site glogSite
+	site.level, site.matched = matchVmodule(r.vmodule, cs.file, cs.function)
+	site.backtrace = r.backtraceLine == cs.line && pathHasSuffix(cs.file, r.backtraceFile)
+	r.sites.Store(pc, site)
+	return site
+}
+
+func (h *GlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	rules := s.rules.Load()
+	if rules == nil || r.PC == 0 {
+		if r.Level < slog.Level(s.verbosity.Load()) {
+			return nil
+		}
 		return h.origin.Handle(ctx, r)
 	}
-	return nil
+
+	site := rules.site(r.PC)
+	if r.Level < slog.Level(s.verbosity.Load()) && (!site.matched || r.Level < site.level) {
+		return nil
+	}
+	if site.backtrace {
+		r = r.Clone()
+		r.AddAttrs(slog.String(ErrorStackFieldName, stackTrace()))
+	}
+	return h.origin.Handle(ctx, r)
 }
 
-// compilePattern converts a vmodule pattern to a compiled regex.
-func compilePattern(pattern string) (*regexp.Regexp, error) {
-	escaped := strings.ReplaceAll(pattern, ".", "\\.")
-	escaped = strings.ReplaceAll(escaped, "*", ".*")
-	escaped = strings.ReplaceAll(escaped, "/", "\\/")
-	return regexp.Compile(escaped)
+// stackTrace returns the stack trace of the calling goroutine, growing its
+// buffer as needed up to 1 MB.
+func stackTrace() string {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) || len(buf) >= 1024*1024 {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
 }
 
+// Enabled reports whether records at level may be logged, either because of
+// the global verbosity or because of a vmodule rule. The rules are checked
+// against the call site in Handle.
 func (h *GlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	h.mu.RLock()
-	verbosity := h.verbosity
-	h.mu.RUnlock()
-	return level >= verbosity
+	return level >= slog.Level(h.state.minLevel.Load())
 }
 
 func (h *GlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	h.mu.RLock()
-	verbosity := h.verbosity
-	vmodule := make(map[string]slog.Level, len(h.vmodule))
-	for k, v := range h.vmodule {
-		vmodule[k] = v
-	}
-	patternCache := make(map[string]*regexp.Regexp, len(h.patternCache))
-	for k, v := range h.patternCache {
-		patternCache[k] = v
-	}
-	h.mu.RUnlock()
-
 	return &GlogHandler{
-		origin:       h.origin.WithAttrs(attrs),
-		verbosity:    verbosity,
-		vmodule:      vmodule,
-		patternCache: patternCache,
+		origin: h.origin.WithAttrs(attrs),
+		state:  h.state.clone(),
 	}
 }
 
 func (h *GlogHandler) WithGroup(name string) slog.Handler {
-	h.mu.RLock()
-	verbosity := h.verbosity
-	vmodule := make(map[string]slog.Level, len(h.vmodule))
-	for k, v := range h.vmodule {
-		vmodule[k] = v
-	}
-	patternCache := make(map[string]*regexp.Regexp, len(h.patternCache))
-	for k, v := range h.patternCache {
-		patternCache[k] = v
-	}
-	h.mu.RUnlock()
-
 	return &GlogHandler{
-		origin:       h.origin.WithGroup(name),
-		verbosity:    verbosity,
-		vmodule:      vmodule,
-		patternCache: patternCache,
+		origin: h.origin.WithGroup(name),
+		state:  h.state.clone(),
 	}
 }
 
@@ -0,0 +1,218 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler is a slog.Handler writing records through a Logger.
+type slogHandler struct {
+	l *logger
+
+	// root is set for the handlers of Root, whose logger is resolved for
+	// each record instead of l.
+	root *rootProxy
+
+	// goas holds the groups and attributes added after the first WithGroup,
+	// or all of them if root is set. The attributes added before it are
+	// encoded in the context of l.
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs is either a group name or a list of attributes.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// SlogHandler returns a slog.Handler writing records through l, so that code
+// logging with log/slog, such as third-party libraries after slog.SetDefault,
+// ends up in the same writers, formats, samplers and hooks as the Logger.
+//
+// Record levels are mapped to the closest Level at or above them, so crit
+// records are logged at FatalLevel (without exiting). Groups are rendered as
+// nested objects, attributes added with WithAttrs are encoded once into the
+// logger context, and the caller field is set from the record PC. The logger
+// and global levels, the named level rules, the vmodule rules and dynamic
+// debug all apply, the latter two to the code that logged the record, as does
+// the level forced by the record context with WithForcedLevel.
+//
+// The timestamp of the events is the record time, if the logger has one.
+//
+// Loggers not created by this package, and disabled loggers, get a handler
+// that discards everything. The handler of Root, or of a child of it, writes
+// each record through the logger set with SetDefault at the time it is
+// handled.
+func SlogHandler(l Logger) slog.Handler {
+	if p, ok := l.(*rootProxy); ok {
+		return &slogHandler{root: p}
+	}
+	ll, ok := l.(*logger)
+	if !ok || ll.IsZero() {
+		return slog.DiscardHandler
+	}
+	return &slogHandler{l: ll}
+}
+
+// logger returns the logger to write through, or nil if there is none.
+func (h *slogHandler) logger() *logger {
+	if h.root == nil {
+		return h.l
+	}
+	if l, ok := h.root.resolve().(*logger); ok && !l.IsZero() {
+		return l
+	}
+	return nil
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	l := h.logger()
+	if l == nil {
+		return false
+	}
+	lvl := levelFromSlog(level)
+	ok, disabled := l.levelPasses(lvl)
+	if ok || disabled {
+		return ok
+	}
+	if l.forcedAt(lvl, ctx) {
+		return true
+	}
+	// The vmodule rules and dynamic debug are checked against the record PC
+	// in Handle.
+	return vmodule.Load() != nil || (lvl <= DebugLevel && dynamicDebug.Load())
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.logger()
+	if l == nil {
+		return nil
+	}
+	e := l.newEventAt(levelFromSlog(r.Level), r.PC, ctx, nil)
+	if e == nil {
+		return nil
+	}
+	e.time = r.Time
+	e.Ctx(ctx)
+	if r.PC != 0 && CallerFieldName != "" {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.buf = enc.AppendString(enc.AppendKey(e.buf, CallerFieldName),
+			CallerMarshalFunc(r.PC, frame.File, frame.Line))
+	}
+	appendGroupOrAttrs(e, h.goas, r)
+	e.Msg(r.Message)
+	return nil
+}
+
+// appendGroupOrAttrs appends goas, nesting groups as objects, followed by the
+// attributes of r in the innermost group. Empty groups are omitted.
+func appendGroupOrAttrs(e *Event, goas []groupOrAttrs, r slog.Record) {
+	for i, goa := range goas {
+		if goa.group == "" {
+			for _, a := range goa.attrs {
+				appendSlogAttr(e, a)
+			}
+			continue
+		}
+		d := e.CreateDict()
+		appendGroupOrAttrs(d, goas[i+1:], r)
+		if len(d.buf) > 1 {
+			e.Dict(goa.group, d)
+		} else {
+			putEvent(d)
+		}
+		return
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		appendSlogAttr(e, a)
+		return true
+	})
+}
+
+// appendSlogAttr appends a to e, following the slog.Handler rules for empty
+// attributes and groups.
+func appendSlogAttr(e *Event, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		e.Str(a.Key, a.Value.String())
+	case slog.KindInt64:
+		e.Int64(a.Key, a.Value.Int64())
+	case slog.KindUint64:
+		e.Uint64(a.Key, a.Value.Uint64())
+	case slog.KindFloat64:
+		e.Float64(a.Key, a.Value.Float64())
+	case slog.KindBool:
+		e.Bool(a.Key, a.Value.Bool())
+	case slog.KindDuration:
+		e.Dur(a.Key, a.Value.Duration())
+	case slog.KindTime:
+		e.Time(a.Key, a.Value.Time())
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				appendSlogAttr(e, ga)
+			}
+			return
+		}
+		d := e.CreateDict()
+		for _, ga := range attrs {
+			appendSlogAttr(d, ga)
+		}
+		e.Dict(a.Key, d)
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			e.AnErr(a.Key, err)
+		} else {
+			e.Interface(a.Key, a.Value.Any())
+		}
+	}
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	// The attributes can only be encoded into the logger context before the
+	// first group, and if the logger is known in advance.
+	if len(h.goas) > 0 || h.root != nil {
+		return &slogHandler{l: h.l, root: h.root, goas: h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})}
+	}
+
+	c := h.l.With()
+	e := c.l.scratchEvent()
+	for _, a := range attrs {
+		appendSlogAttr(e, a)
+	}
+	// An event without fields only holds the begin marker.
+	if len(e.buf) > 1 {
+		c.l.context = enc.AppendObjectData(c.l.context, e.buf)
+	}
+	putEvent(e)
+	return &slogHandler{l: c.l}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{l: h.l, root: h.root, goas: h.withGroupOrAttrs(groupOrAttrs{group: name})}
+}
+
+func (h *slogHandler) withGroupOrAttrs(goa groupOrAttrs) []groupOrAttrs {
+	goas := make([]groupOrAttrs, len(h.goas)+1)
+	copy(goas, h.goas)
+	goas[len(h.goas)] = goa
+	return goas
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf).With().Str("component", "test").Logger().Level(InfoLevel)
+	sl := slog.New(SlogHandler(l))
+
+	saved := CallerFieldName
+	CallerFieldName = ""
+	defer func() { CallerFieldName = saved }()
+
+	sl.Debug("dropped")
+	sl.With("a", 1).WithGroup("g").With("b", true).Info("msg",
+		"err", errors.New("boom"),
+		slog.Group("h", "c", "x"),
+		slog.Group("empty"),
+	)
+	sl.WithGroup("g").Warn("empty group")
+	sl.Log(context.Background(), slogLevelCrit, "crit")
+
+	want := `{"level":"info","component":"test","a":1,"g":{"b":true,"err":"boom","h":{"c":"x"}},"message":"msg"}` + "\n" +
+		`{"level":"warn","component":"test","message":"empty group"}` + "\n" +
+		`{"level":"fatal","component":"test","message":"crit"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSlogHandlerRecordTime(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf).With().Timestamp().Logger()
+	h := SlogHandler(l)
+
+	saved := CallerFieldName
+	CallerFieldName = ""
+	defer func() { CallerFieldName = saved }()
+
+	r := slog.NewRecord(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), slog.LevelInfo, "msg", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"level":"info","time":"2020-01-02T03:04:05Z","message":"msg"}` + "\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSlogHandlerRoot(t *testing.T) {
+	saved := rootLogger()
+	defer SetDefault(saved)
+
+	savedCaller := CallerFieldName
+	CallerFieldName = ""
+	defer func() { CallerFieldName = savedCaller }()
+
+	// Obtained before the application configures logging, as in init.
+	sl := slog.New(SlogHandler(Root().New("module", "p2p"))).With("a", 1)
+
+	var first, second bytes.Buffer
+	SetDefault(NewWriter(&first))
+	sl.Info("one")
+	SetDefault(NewWriter(&second).Level(WarnLevel))
+	sl.Info("dropped")
+	sl.WithGroup("g").Warn("two", "b", 2)
+
+	if want := `{"level":"info","module":"p2p","a":1,"message":"one"}` + "\n"; first.String() != want {
+		t.Errorf("got %q, want %q", first.String(), want)
+	}
+	if want := `{"level":"warn","module":"p2p","a":1,"g":{"b":2},"message":"two"}` + "\n"; second.String() != want {
+		t.Errorf("got %q, want %q", second.String(), want)
+	}
+}
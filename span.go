@@ -0,0 +1,92 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// Span is an operation logged by Logger.Start, such as the processing of a
+// block. It embeds the logger of the operation, whose events carry the
+// SpanFieldName field, the ParentSpanFieldName field of the enclosing span if
+// any, and the fields given to Start.
+type Span struct {
+	Logger
+
+	name      string
+	start     time.Time
+	threshold time.Duration
+	ended     atomic.Bool
+}
+
+type spanKey struct{}
+
+// startSpan implements Logger.Start for l.
+func startSpan(l Logger, ctx context.Context, name string, kv []interface{}) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	id := newSpanID()
+	fields := make([]interface{}, 0, 4+len(kv))
+	fields = append(fields, SpanFieldName, id)
+	if parent, ok := ctx.Value(spanKey{}).(string); ok {
+		fields = append(fields, ParentSpanFieldName, parent)
+	}
+	fields = append(fields, kv...)
+
+	ctx = context.WithValue(ctx, spanKey{}, id)
+	sl := l.New(fields...)
+	s := &Span{Logger: sl, name: name, start: time.Now(), threshold: SlowSpanThreshold}
+	if e := sl.DebugEvent(); e.Enabled() {
+		// Skip startSpan and the Start method.
+		e.CallerSkipFrame(2).Msg(name + " started")
+	}
+	return WithContext(ctx, sl), s
+}
+
+// newSpanID returns a random span ID as 16 hex digits.
+func newSpanID() string {
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	return hex.EncodeToString(id[:])
+}
+
+// SlowAfter sets the duration above which the span is logged as slow when it
+// ends, instead of SlowSpanThreshold. Spans are never slow if d is 0.
+func (s *Span) SlowAfter(d time.Duration) *Span {
+	s.threshold = d
+	return s
+}
+
+// End logs the end of the span with its elapsed time and outcome: "error", at
+// error level with err, if err is not nil, "slow", at warn level, if it took
+// longer than its threshold, or "ok", at debug level. Only the first call
+// logs.
+func (s *Span) End(err error) {
+	if !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+	elapsed := time.Since(s.start)
+	lvl, outcome := DebugLevel, "ok"
+	switch {
+	case err != nil:
+		lvl, outcome = ErrorLevel, "error"
+	case s.threshold > 0 && elapsed > s.threshold:
+		lvl, outcome = WarnLevel, "slow"
+	}
+	e := s.Logger.WithLevel(lvl)
+	if !e.Enabled() {
+		return
+	}
+	e.Dur(ElapsedFieldName, elapsed).Str(OutcomeFieldName, outcome)
+	if err != nil {
+		e.Err(err)
+	}
+	e.CallerSkipFrame(1).Msg(s.name + " finished")
+}
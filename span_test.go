@@ -0,0 +1,85 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpan(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf)
+
+	ctx, outer := l.Start(context.Background(), "sync-block", "height", 7)
+	ctx, inner := Ctx(ctx).Start(ctx, "verify")
+	inner.Info("checked")
+	inner.End(errors.New("bad block"))
+	inner.End(nil)
+	outer.SlowAfter(time.Nanosecond).End(nil)
+	Ctx(ctx).Info("after")
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, m)
+	}
+	if len(lines) != 6 {
+		t.Fatalf("got %d lines, want 6:\n%s", len(lines), buf.String())
+	}
+	outerID, innerID := lines[0][SpanFieldName], lines[1][SpanFieldName]
+	if outerID == nil || outerID == innerID || lines[1][ParentSpanFieldName] != outerID {
+		t.Errorf("spans are not nested: %v", lines[:2])
+	}
+	if lines[0]["message"] != "sync-block started" || lines[0]["height"] != 7.0 {
+		t.Errorf("bad start event: %v", lines[0])
+	}
+	if end := lines[3]; end["level"] != "error" || end[OutcomeFieldName] != "error" ||
+		end["error"] != "bad block" || end[ElapsedFieldName] == nil || end[SpanFieldName] != innerID {
+		t.Errorf("bad failed end event: %v", end)
+	}
+	if end := lines[4]; end["level"] != "warn" || end[OutcomeFieldName] != "slow" ||
+		end["message"] != "sync-block finished" {
+		t.Errorf("bad slow end event: %v", end)
+	}
+	if lines[5][SpanFieldName] != innerID {
+		t.Errorf("context logger is not the span logger: %v", lines[5])
+	}
+}
+
+func TestSpanDebugDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel)
+
+	ctx, outer := l.Start(context.Background(), "sync-block", "height", 7)
+	_, inner := Ctx(ctx).Start(ctx, "verify")
+	inner.End(errors.New("bad block"))
+	outer.End(errors.New("bad block"))
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, m)
+	}
+	// Only the begin events are skipped.
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	innerEnd, outerEnd := lines[0], lines[1]
+	if outerEnd[SpanFieldName] == nil || outerEnd["height"] != 7.0 ||
+		innerEnd[ParentSpanFieldName] != outerEnd[SpanFieldName] || innerEnd[SpanFieldName] == nil {
+		t.Errorf("spans are not nested: %v", lines)
+	}
+}
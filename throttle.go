@@ -0,0 +1,190 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Throttle logs through a logger at a limited rate per call site, as returned
+// by Logger.Every and Logger.FirstN. Unlike samplers, which apply to every
+// event of a logger, a throttle keeps a separate budget for each line of code
+// logging through it, or for each key set with Key, so one noisy warning does
+// not hide the others. The first event logged after some were suppressed
+// carries their number in the SuppressedFieldName field.
+//
+//	l.Every(10*time.Second).Warn("mempool full", "size", size)
+//
+// Events dropped by the logger level are not counted as suppressed, and no
+// event is created for the suppressed ones.
+type Throttle struct {
+	l      Logger
+	every  time.Duration
+	firstN bool
+	n      int
+	key    string
+}
+
+// throttleKey identifies the budget of a throttled call site.
+type throttleKey struct {
+	pc     uintptr
+	key    string
+	every  time.Duration
+	firstN bool
+	n      int
+}
+
+type throttleState struct {
+	mu         sync.Mutex
+	last       time.Time
+	count      int
+	suppressed int64
+}
+
+// throttleSetSize bounds the number of budgets kept by throttles. Past it, the
+// oldest budgets are forgotten, and their call sites or keys start afresh.
+const throttleSetSize = 4096
+
+// throttleSet holds the throttleState of each throttleKey.
+type throttleSet struct {
+	states sync.Map
+
+	mu sync.Mutex
+	// ring holds the keys of states in insertion order, from next.
+	ring []throttleKey
+	next int
+}
+
+var throttles = &throttleSet{}
+
+// state returns the budget of k, creating it if needed.
+func (s *throttleSet) state(k throttleKey) *throttleState {
+	if v, ok := s.states.Load(k); ok {
+		return v.(*throttleState)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.states.Load(k); ok {
+		return v.(*throttleState)
+	}
+	if len(s.ring) < throttleSetSize {
+		s.ring = append(s.ring, k)
+	} else {
+		s.states.Delete(s.ring[s.next])
+		s.ring[s.next] = k
+		s.next = (s.next + 1) % throttleSetSize
+	}
+	st := &throttleState{}
+	s.states.Store(k, st)
+	return st
+}
+
+// Every returns a throttle logging at most one event per interval and call
+// site. Nothing is throttled if interval is not positive.
+func (l *logger) Every(interval time.Duration) Throttle {
+	return Throttle{l: l, every: interval}
+}
+
+// FirstN returns a throttle logging the first n events of each call site
+// only.
+func (l *logger) FirstN(n int) Throttle {
+	return Throttle{l: l, firstN: true, n: n}
+}
+
+// Key returns a copy of the throttle whose budget is shared by the call sites
+// using key, instead of being kept per call site. The keys must come from a
+// bounded set, such as event kinds, not peer or transaction IDs.
+func (t Throttle) Key(key string) Throttle {
+	t.key = key
+	return t
+}
+
+func (t Throttle) Trace(msg string, ctx ...interface{}) {
+	t.log(TraceLevel, msg, ctx)
+}
+
+func (t Throttle) Debug(msg string, ctx ...interface{}) {
+	t.log(DebugLevel, msg, ctx)
+}
+
+func (t Throttle) Info(msg string, ctx ...interface{}) {
+	t.log(InfoLevel, msg, ctx)
+}
+
+func (t Throttle) Warn(msg string, ctx ...interface{}) {
+	t.log(WarnLevel, msg, ctx)
+}
+
+func (t Throttle) Error(msg string, ctx ...interface{}) {
+	t.log(ErrorLevel, msg, ctx)
+}
+
+// Log logs at level, if the budget of the call site allows it.
+func (t Throttle) Log(level Level, msg string, ctx ...interface{}) {
+	t.log(level, msg, ctx)
+}
+
+func (t Throttle) log(level Level, msg string, ctx []interface{}) {
+	if !levelEnabled(t.l, level) {
+		return
+	}
+	ok, suppressed := t.allow()
+	if !ok {
+		return
+	}
+	e := t.l.WithLevel(level)
+	if suppressed > 0 {
+		e.Int64(SuppressedFieldName, suppressed)
+	}
+	applyContext(e, ctx).CallerSkipFrame(1).Msg(msg)
+}
+
+// levelEnabled reports whether l logs the events at level of its caller,
+// before they are sampled.
+func levelEnabled(l Logger, level Level) bool {
+	if level == Disabled {
+		return false
+	}
+	ll, ok := resolveRoot(l).(*logger)
+	if !ok {
+		return l.Enabled(context.Background(), levelToSlog(level))
+	}
+	return ll.enabledAt(level, 0) || ll.forcedAt(level, ll.ctx)
+}
+
+// allow reports whether the budget of the call site allows an event, and the
+// number of events suppressed since the last one allowed.
+func (t Throttle) allow() (bool, int64) {
+	k := throttleKey{key: t.key, every: t.every, firstN: t.firstN, n: t.n}
+	if k.key == "" {
+		s := callerSite()
+		if s == nil {
+			return true, 0
+		}
+		k.pc = s.pc
+	}
+	s := throttles.state(k)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !t.firstN {
+		now := time.Now()
+		if !s.last.IsZero() && now.Sub(s.last) < t.every {
+			s.suppressed++
+			return false, 0
+		}
+		s.last = now
+	} else {
+		if s.count >= t.n {
+			s.suppressed++
+			return false, 0
+		}
+		s.count++
+	}
+	suppressed := s.suppressed
+	s.suppressed = 0
+	return true, suppressed
+}
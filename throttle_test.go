@@ -0,0 +1,78 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// freshThrottles replaces the throttle states until the test ends.
+func freshThrottles(t *testing.T) {
+	saved := throttles
+	throttles = &throttleSet{}
+	t.Cleanup(func() { throttles = saved })
+}
+
+func TestThrottle(t *testing.T) {
+	freshThrottles(t)
+	var buf bytes.Buffer
+	l := NewWriter(&buf)
+
+	for i := 0; i < 5; i++ {
+		l.FirstN(2).Info("first", "i", i)
+	}
+	// Call sites have separate budgets, keys shared ones.
+	l.FirstN(1).Info("other site")
+	l.FirstN(1).Key("k").Info("key")
+	l.FirstN(1).Key("k").Info("key again")
+
+	for i := 0; i < 3; i++ {
+		l.Every(50*time.Millisecond).Key("every").Warn("every", "i", i)
+	}
+	time.Sleep(60 * time.Millisecond)
+	l.Every(50 * time.Millisecond).Key("every").Warn("resumed")
+
+	want := `{"level":"info","i":0,"message":"first"}` + "\n" +
+		`{"level":"info","i":1,"message":"first"}` + "\n" +
+		`{"level":"info","message":"other site"}` + "\n" +
+		`{"level":"info","message":"key"}` + "\n" +
+		`{"level":"warn","i":0,"message":"every"}` + "\n" +
+		`{"level":"warn","suppressed":2,"message":"resumed"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestThrottleSetSize(t *testing.T) {
+	freshThrottles(t)
+	var buf bytes.Buffer
+	l := NewWriter(&buf)
+
+	l.FirstN(1).Key("first").Info("first")
+	for i := 0; i < throttleSetSize; i++ {
+		l.FirstN(1).Key(strconv.Itoa(i)).Debug("filler")
+	}
+	buf.Reset()
+	// The oldest budget was forgotten.
+	l.FirstN(1).Key("first").Info("first")
+	if got, want := buf.String(), `{"level":"info","message":"first"}`+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestThrottleAllocs(t *testing.T) {
+	freshThrottles(t)
+	l := NewWriter(io.Discard)
+	l.FirstN(1).Info("first")
+	allocs := testing.AllocsPerRun(100, func() {
+		l.FirstN(1).Info("first")
+	})
+	if allocs != 0 {
+		t.Errorf("suppressed events allocate %v times", allocs)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ContextExtractor appends fields derived from the Go context of an event,
+// such as tracing identifiers. Extractors are registered per logger with
+// Context.Extractor and run when the event is sent, with the context given to
+// Event.Ctx or Context.Ctx. They must not allocate when the context carries
+// nothing for them.
+type ContextExtractor interface {
+	Extract(ctx context.Context, e *Event)
+}
+
+// ContextExtractorFunc is an adaptor to allow the use of an ordinary function
+// as a ContextExtractor.
+type ContextExtractorFunc func(ctx context.Context, e *Event)
+
+// Extract implements the ContextExtractor interface.
+func (f ContextExtractorFunc) Extract(ctx context.Context, e *Event) {
+	f(ctx, e)
+}
+
+// ContextExtractors chains extractors, running them in order.
+type ContextExtractors []ContextExtractor
+
+// Extract implements the ContextExtractor interface.
+func (xs ContextExtractors) Extract(ctx context.Context, e *Event) {
+	for _, x := range xs {
+		x.Extract(ctx, e)
+	}
+}
+
+// extractorHook runs a ContextExtractor on the events that have a context.
+type extractorHook struct {
+	x ContextExtractor
+}
+
+func (h extractorHook) Run(e *Event, _ Level, _ string) {
+	if e.ctx != nil {
+		h.x.Extract(e.ctx, e)
+	}
+}
+
+// Extractor registers extractors to run on the events of the logger that
+// carry a Go context. Use TraceContextExtractor to log the span context set
+// with ContextWithSpanContext.
+func (c Context) Extractor(extractors ...ContextExtractor) Context {
+	switch len(extractors) {
+	case 0:
+		return c
+	case 1:
+		c.l = c.l.hook(extractorHook{x: extractors[0]})
+	default:
+		c.l = c.l.hook(extractorHook{x: ContextExtractors(extractors)})
+	}
+	return c
+}
+
+// SpanContext identifies a span of a distributed trace, as propagated by the
+// W3C traceparent header.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether the trace and span IDs are not all zeros.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Sampled reports whether the sampled flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&1 != 0
+}
+
+// Traceparent formats the span context as a W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses a W3C traceparent header value, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Versions above
+// 00 are accepted as long as they start with the version 00 fields. The
+// fields must be lowercase hex, as the specification requires.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' || (len(s) > 55 && s[55] != '-') ||
+		hasUpperHex(s[:55]) {
+		return sc, errInvalidTraceparent
+	}
+	var version [1]byte
+	if _, err := hex.Decode(version[:], []byte(s[:2])); err != nil || version[0] == 0xff ||
+		(version[0] == 0 && len(s) != 55) {
+		return sc, errInvalidTraceparent
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(s[3:35])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(s[36:52])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(flags[:], []byte(s[53:55])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return sc, errInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// hasUpperHex reports whether s holds an uppercase hex digit.
+func hasUpperHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if 'A' <= s[i] && s[i] <= 'F' {
+			return true
+		}
+	}
+	return false
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx carrying sc, for
+// TraceContextExtractor.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// ContextWithTraceparent parses the W3C traceparent header value and returns
+// a copy of ctx carrying the span context, for TraceContextExtractor.
+func ContextWithTraceparent(ctx context.Context, traceparent string) (context.Context, error) {
+	sc, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return ctx, err
+	}
+	return ContextWithSpanContext(ctx, sc), nil
+}
+
+// SpanContextFromContext returns the span context attached to ctx with
+// ContextWithSpanContext.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// TraceContextExtractor adds the trace and span IDs of the span context
+// attached with ContextWithSpanContext to events, as hex strings under
+// TraceIDFieldName and SpanIDFieldName.
+var TraceContextExtractor ContextExtractor = ContextExtractorFunc(extractTraceContext)
+
+func extractTraceContext(ctx context.Context, e *Event) {
+	sc, ok := SpanContextFromContext(ctx)
+	if !ok || !sc.IsValid() {
+		return
+	}
+	e.Hex(TraceIDFieldName, sc.TraceID[:])
+	e.Hex(SpanIDFieldName, sc.SpanID[:])
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestTraceContextExtractor(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := ContextWithTraceparent(context.Background(), traceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, _ := SpanContextFromContext(ctx)
+	if !sc.Sampled() || sc.Traceparent() != traceparent {
+		t.Errorf("unexpected span context %+v", sc)
+	}
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf).With().Extractor(TraceContextExtractor).Logger()
+	l.InfoEvent().Ctx(ctx).Msg("traced")
+	l.InfoEvent().Ctx(context.Background()).Msg("untraced")
+
+	want := `{"level":"info","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","message":"traced"}` + "\n" +
+		`{"level":"info","message":"untraced"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	discard := NewWriter(nil).With().Extractor(TraceContextExtractor).Logger()
+	untraced := context.Background()
+	if allocs := testing.AllocsPerRun(100, func() {
+		discard.InfoEvent().Ctx(untraced).Msg("")
+	}); allocs != 0 {
+		t.Errorf("got %v allocs for an untraced event, want 0", allocs)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00F067AA0BA902B7-01",
+		"0A-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
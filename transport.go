@@ -0,0 +1,237 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedactedHeaders lists the headers whose values LoggingTransport
+// redacts unless RedactHeaders is set.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// redacted replaces the values of redacted headers.
+const redacted = "[REDACTED]"
+
+// LoggingTransport is an http.RoundTripper logging outbound HTTP calls. Each
+// call is logged with its method, host, path, status, latency, retry count
+// (see ContextWithRetry) and error, at debug level, or at warn level if it
+// failed or got a 5xx response. At trace level, the headers are logged too,
+// with the values of RedactHeaders redacted, and the first MaxBodyCapture
+// bytes of the request and response bodies. The bodies are captured as they
+// are read, so a call capturing them is logged once the caller has read the
+// response body to the end or closed it.
+//
+// The logger attached to the request context with WithContext is preferred
+// over Logger, and the request context is given to the events, so the fields
+// attached with ContextWith and the context extractors apply.
+type LoggingTransport struct {
+	// Logger logs the calls of requests without a context logger.
+	Logger Logger
+
+	// Next performs the calls. http.DefaultTransport is used if nil.
+	Next http.RoundTripper
+
+	// RedactHeaders lists the headers whose values are redacted.
+	// DefaultRedactedHeaders is used if nil.
+	RedactHeaders []string
+
+	// MaxBodyCapture is the number of bytes of the request and response
+	// bodies logged at trace level. Bodies are not captured if 0.
+	MaxBodyCapture int
+}
+
+// Transport returns a LoggingTransport logging the calls made through next
+// with l.
+func Transport(l Logger, next http.RoundTripper) *LoggingTransport {
+	return &LoggingTransport{Logger: l, Next: next}
+}
+
+type retryKey struct{}
+
+// ContextWithRetry returns a copy of ctx carrying the retry number of a
+// request, 0 for the first attempt, logged by LoggingTransport.
+func ContextWithRetry(ctx context.Context, retry int) context.Context {
+	return context.WithValue(ctx, retryKey{}, retry)
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	ctx := req.Context()
+	l, ok := ctx.Value(ctxKey{}).(Logger)
+	if !ok {
+		l = t.Logger
+	}
+	if l == nil {
+		return next.RoundTrip(req)
+	}
+
+	c := &httpCall{l: l, ctx: ctx, trace: l.Enabled(ctx, slogLevelTrace)}
+	capture := c.trace && t.MaxBodyCapture > 0
+	if capture && req.Body != nil && req.Body != http.NoBody {
+		c.reqBody = &bodyCapture{body: req.Body, limit: t.MaxBodyCapture}
+		// The request of the caller must not be modified.
+		req = req.Clone(ctx)
+		req.Body = c.reqBody
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	c.req, c.resp, c.err, c.latency = req, resp, err, time.Since(start)
+
+	// The response body is captured as the caller reads it, and the call
+	// logged once it is read or closed, so that streaming is not delayed.
+	// Upgraded connections are left alone, as their body is also written to.
+	if capture && resp != nil && resp.Body != nil && resp.Body != http.NoBody &&
+		resp.StatusCode != http.StatusSwitchingProtocols {
+		body := &bodyCapture{body: resp.Body, limit: t.MaxBodyCapture}
+		body.done = func() { t.log(c, body.captured()) }
+		resp.Body = body
+		return resp, err
+	}
+	t.log(c, nil)
+	return resp, err
+}
+
+// httpCall is an outbound call made through a LoggingTransport.
+type httpCall struct {
+	l       Logger
+	ctx     context.Context
+	trace   bool
+	req     *http.Request
+	reqBody *bodyCapture
+	resp    *http.Response
+	err     error
+	latency time.Duration
+}
+
+// log logs c, with the captured response body respBody.
+func (t *LoggingTransport) log(c *httpCall, respBody []byte) {
+	lvl := DebugLevel
+	if c.err != nil || (c.resp != nil && c.resp.StatusCode >= http.StatusInternalServerError) {
+		lvl = WarnLevel
+	}
+	e := c.l.WithLevel(lvl)
+	if !e.Enabled() {
+		return
+	}
+	e.Ctx(c.ctx).
+		Str("method", c.req.Method).
+		Str("host", c.req.URL.Host).
+		Str("path", c.req.URL.Path)
+	if c.resp != nil {
+		e.Int("status", c.resp.StatusCode)
+	}
+	e.Dur("latency", c.latency)
+	if retry, ok := c.ctx.Value(retryKey{}).(int); ok && retry > 0 {
+		e.Int("retry", retry)
+	}
+	if c.err != nil {
+		e.Err(c.err)
+	}
+	if c.trace {
+		e.Dict("request_headers", t.headers(c.req.Header))
+		if c.resp != nil {
+			e.Dict("response_headers", t.headers(c.resp.Header))
+		}
+		if c.reqBody != nil {
+			e.Bytes("request_body", c.reqBody.captured())
+		}
+		if respBody != nil {
+			e.Bytes("response_body", respBody)
+		}
+	}
+	e.Msg("http call")
+}
+
+// headers returns h as a dict, with the values of redacted headers replaced.
+func (t *LoggingTransport) headers(h http.Header) *Event {
+	redact := t.RedactHeaders
+	if redact == nil {
+		redact = DefaultRedactedHeaders
+	}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	d := Dict()
+	for _, k := range keys {
+		v := strings.Join(h[k], ", ")
+		for _, r := range redact {
+			if strings.EqualFold(k, r) {
+				v = redacted
+				break
+			}
+		}
+		d.Str(k, v)
+	}
+	return d
+}
+
+// bodyCapture is a body keeping a copy of the first limit bytes read through
+// it. done, if set, is called once the body is read to the end, fails or is
+// closed.
+type bodyCapture struct {
+	body  io.ReadCloser
+	limit int
+	done  func()
+
+	mu   sync.Mutex
+	buf  []byte
+	once sync.Once
+}
+
+func (c *bodyCapture) Read(p []byte) (int, error) {
+	n, err := c.body.Read(p)
+	c.mu.Lock()
+	if rest := c.limit - len(c.buf); rest > 0 {
+		c.buf = append(c.buf, p[:min(n, rest)]...)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *bodyCapture) Close() error {
+	err := c.body.Close()
+	c.finish()
+	return err
+}
+
+func (c *bodyCapture) finish() {
+	if c.done != nil {
+		c.once.Do(c.done)
+	}
+}
+
+// captured returns the bytes captured so far, or nil if c is nil.
+func (c *bodyCapture) captured() []byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.buf)
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write(append([]byte("echo:"), body...))
+	}))
+	defer srv.Close()
+
+	saved := GlobalLevel()
+	SetGlobalLevel(TraceLevel)
+	defer SetGlobalLevel(saved)
+
+	var buf bytes.Buffer
+	tr := Transport(NewWriter(&buf), nil)
+	tr.MaxBodyCapture = 4
+	client := &http.Client{Transport: tr}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/rpc", strings.NewReader("payload"))
+	req.Header.Set("Authorization", "Bearer token")
+	req = req.WithContext(ContextWithRetry(req.Context(), 2))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "echo:payload" {
+		t.Errorf("got body %q, want the full body", body)
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid line %q: %v", buf.String(), err)
+	}
+	if line["method"] != "POST" || line["path"] != "/rpc" || line["status"] != float64(200) || line["retry"] != float64(2) {
+		t.Errorf("unexpected line %v", line)
+	}
+	if line["request_body"] != "payl" || line["response_body"] != "echo" {
+		t.Errorf("unexpected captured bodies %v %v", line["request_body"], line["response_body"])
+	}
+	reqHeaders, _ := line["request_headers"].(map[string]interface{})
+	respHeaders, _ := line["response_headers"].(map[string]interface{})
+	if reqHeaders["Authorization"] != redacted || respHeaders["Set-Cookie"] != redacted {
+		t.Errorf("headers not redacted: %v %v", reqHeaders, respHeaders)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// errReader returns its data, then err.
+type errReader struct {
+	data []byte
+	err  error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestTransportStreaming(t *testing.T) {
+	saved := GlobalLevel()
+	SetGlobalLevel(TraceLevel)
+	defer SetGlobalLevel(saved)
+
+	pr, pw := io.Pipe()
+	var buf bytes.Buffer
+	tr := Transport(NewWriter(&buf), roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: pr}, nil
+	}))
+	tr.MaxBodyCapture = 4
+
+	// The response is returned before its body is written.
+	resp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/stream", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("call logged before its body was read: %q", buf.String())
+	}
+	go func() {
+		_, _ = pw.Write([]byte("chunk1"))
+		_, _ = pw.Write([]byte("chunk2"))
+		pw.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "chunk1chunk2" {
+		t.Fatalf("got body %q, %v", body, err)
+	}
+	resp.Body.Close()
+
+	if n := strings.Count(buf.String(), "\n"); n != 1 {
+		t.Fatalf("got %d lines, want 1: %q", n, buf.String())
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["response_body"] != "chun" {
+		t.Errorf("got response body %v", line["response_body"])
+	}
+}
+
+func TestTransportBodyError(t *testing.T) {
+	saved := GlobalLevel()
+	SetGlobalLevel(TraceLevel)
+	defer SetGlobalLevel(saved)
+
+	errBody := errors.New("connection reset")
+	var buf bytes.Buffer
+	tr := Transport(NewWriter(&buf), roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := &errReader{data: []byte("partial"), err: errBody}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(body)}, nil
+	}))
+	tr.MaxBodyCapture = 64
+
+	resp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, errBody) || string(body) != "partial" {
+		t.Errorf("got %q, %v, want %q, %v", body, err, "partial", errBody)
+	}
+	if !strings.Contains(buf.String(), `"response_body":"partial"`) {
+		t.Errorf("unexpected line %q", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"context"
+)
+
+// TriggerScope holds back the verbose lines of a request or operation, and
+// writes them out only if it fails. It binds a TriggerLevelWriter, whose
+// buffer comes from a pool, to a child logger logging at a lower level than
+// its parent: the lines below the parent level are buffered, up to
+// TriggerScopeMaxBufferSize bytes, and flushed once an error-level line is
+// logged or the scope ends with an error. Otherwise they are discarded when
+// the scope ends, and the buffer is recycled.
+//
+//	scope := log.NewTriggerScope(l, log.DebugLevel)
+//	defer func() { scope.End(err) }()
+//	ctx = scope.Context(ctx)
+//
+// The lines at or above the parent level are written as usual, and the
+// global level still applies.
+type TriggerScope struct {
+	l Logger
+	w *TriggerLevelWriter
+}
+
+// NewTriggerScope returns a scope whose logger logs at lvl through l,
+// buffering the lines below the level of l. Loggers not created by this
+// package, and loggers already logging at lvl, are used as is.
+//
+// The level of l is the one the named level rules assign to it, if any, at
+// the time the scope is created. The logger of the scope keeps the name of l,
+// but the rules no longer apply to it.
+func NewTriggerScope(l Logger, lvl Level) *TriggerScope {
+	ll, ok := resolveRoot(l).(*logger)
+	if !ok || ll.IsZero() {
+		return &TriggerScope{l: l}
+	}
+	threshold := ll.threshold()
+	if threshold <= lvl {
+		return &TriggerScope{l: l}
+	}
+	c := ll.With()
+	w := &TriggerLevelWriter{
+		Writer:           c.l.w,
+		ConditionalLevel: threshold - 1,
+		TriggerLevel:     ErrorLevel,
+		MaxBufferSize:    TriggerScopeMaxBufferSize,
+	}
+	c.l.w = w
+	c.l.level = NewLevelVar(lvl)
+	c.l.name = explicitName(ll.name)
+	return &TriggerScope{l: c.Logger(), w: w}
+}
+
+// Logger returns the logger of the scope. It must not be used after End.
+func (s *TriggerScope) Logger() Logger {
+	return s.l
+}
+
+// Context returns a copy of ctx with the logger of the scope attached, see
+// WithContext.
+func (s *TriggerScope) Context(ctx context.Context) context.Context {
+	return WithContext(ctx, s.l)
+}
+
+// Trigger writes out the buffered lines, and the following ones as they are
+// logged.
+func (s *TriggerScope) Trigger() error {
+	if s.w == nil {
+		return nil
+	}
+	return s.w.Trigger()
+}
+
+// End ends the scope, writing out the buffered lines if err is not nil and
+// discarding them otherwise, and returns the buffer to the pool. It returns
+// the error of the destination writer, if any.
+func (s *TriggerScope) End(err error) error {
+	if s.w == nil {
+		return nil
+	}
+	var werr error
+	if err != nil {
+		werr = s.w.Trigger()
+	}
+	s.w.Close()
+	return werr
+}
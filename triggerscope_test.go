@@ -0,0 +1,95 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTriggerScope(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel)
+
+	ok := NewTriggerScope(l, DebugLevel)
+	ok.Logger().Debug("discarded")
+	ok.Logger().Info("kept")
+	if err := ok.End(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := NewTriggerScope(l, DebugLevel)
+	failed.Logger().Debug("flushed")
+	if err := failed.End(errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"level":"info","message":"kept"}` + "\n" +
+		`{"level":"debug","message":"flushed"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTriggerScopeNamed(t *testing.T) {
+	defer SetNamedLevels("")
+	if err := SetNamedLevels("p2p=warn"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel).Named("p2p")
+
+	ok := NewTriggerScope(l, DebugLevel)
+	ok.Logger().Debug("discarded")
+	ok.Logger().Info("discarded")
+	ok.Logger().Warn("kept")
+	if err := ok.End(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := NewTriggerScope(l, DebugLevel)
+	failed.Logger().Info("flushed")
+	if err := failed.End(errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"level":"warn","logger":"p2p","message":"kept"}` + "\n" +
+		`{"level":"info","logger":"p2p","message":"flushed"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTriggerLevelWriterMaxBufferSize(t *testing.T) {
+	var buf bytes.Buffer
+	w := &TriggerLevelWriter{
+		Writer:           &buf,
+		ConditionalLevel: DebugLevel,
+		TriggerLevel:     ErrorLevel,
+		MaxBufferSize:    8,
+	}
+	defer w.Close()
+	for _, line := range []string{"one\n", "two\n", "three\n", "much too long\n"} {
+		if _, err := w.WriteLevel(DebugLevel, []byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Trigger(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "three\n" {
+		t.Errorf("got %q, want the newest line fitting the limit", got)
+	}
+
+	buf.Reset()
+	w.Reset()
+	if _, err := w.WriteLevel(DebugLevel, []byte("again\n")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Reset did not untrigger the writer: %q", buf.String())
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// vmoduleRule enables the call sites matching re from level up.
+type vmoduleRule struct {
+	pattern string
+	re      *regexp.Regexp
+	level   slog.Level
+}
+
+// parseVmodule parses a comma-separated list of pattern=level rules, where
+// level is a level name or a legacy geth verbosity number (0 for crit to 5
+// for trace). A '*' in the pattern matches any sequence of characters.
+// Invalid rules are skipped if skipInvalid is set, and an error otherwise.
+func parseVmodule(spec string, skipInvalid bool) ([]vmoduleRule, error) {
+	var rules []vmoduleRule
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		r, err := parseVmoduleRule(rule)
+		if err != nil {
+			if skipInvalid {
+				continue
+			}
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func parseVmoduleRule(rule string) (vmoduleRule, error) {
+	pattern, levelStr, ok := strings.Cut(rule, "=")
+	pattern = strings.TrimSpace(pattern)
+	levelStr = strings.TrimSpace(levelStr)
+	if !ok || pattern == "" {
+		return vmoduleRule{}, fmt.Errorf("invalid vmodule rule %q: expected pattern=level", rule)
+	}
+	level, err := LvlFromString(levelStr)
+	if err != nil {
+		lvl, convErr := strconv.Atoi(levelStr)
+		if convErr != nil {
+			return vmoduleRule{}, fmt.Errorf("invalid vmodule rule %q: %w", rule, err)
+		}
+		level = FromLegacyLevel(lvl)
+	}
+	re, err := compileVmodulePattern(pattern)
+	if err != nil {
+		return vmoduleRule{}, fmt.Errorf("invalid vmodule rule %q: %w", rule, err)
+	}
+	return vmoduleRule{pattern: pattern, re: re, level: level}, nil
+}
+
+// compileVmodulePattern compiles a vmodule pattern into a regular expression
+// where '*' matches any sequence of characters. The pattern must start at the
+// beginning of a path element, and end at the end of one or before a '.', so
+// that "processor.go" does not match "myprocessor.go" and "luxfi/node/p2p"
+// matches "github.com/luxfi/node/p2p.(*Peer).Send" but not
+// "luxfi/node/p2pool".
+func compileVmodulePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`(^|/)` + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + `($|[/.])`)
+}
+
+// matchVmodule returns the level of the first rule matching the file path or the
+// qualified function name of the call site.
+func matchVmodule(rules []vmoduleRule, file, function string) (slog.Level, bool) {
+	for _, rule := range rules {
+		if rule.re.MatchString(file) || rule.re.MatchString(function) {
+			return rule.level, true
+		}
+	}
+	return 0, false
+}
+
+// vmoduleRules is an immutable set of rules for the Logger. gen changes with
+// every SetVmodule call so that call sites can cache their resolved level.
+type vmoduleRules struct {
+	spec  string
+	rules []vmoduleRule
+	gen   uint64
+	// min is the lowest level of the rules, below which no call site is
+	// looked up.
+	min Level
+}
+
+var (
+	vmodule    atomic.Pointer[vmoduleRules]
+	vmoduleGen atomic.Uint64
+)
+
+// SetVmodule sets per-file, per-package and per-function verbosity rules for
+// the event-based Logger, using the pattern=level syntax of
+// GlogHandler.Vmodule, e.g. "processor.go=trace,luxfi/node/p2p=debug". A rule
+// matches a call site if its pattern matches the file path or the package
+// qualified function name (such as "github.com/luxfi/node/chain.(*Processor).Process")
+// of the code calling the logger. The first matching rule applies.
+//
+// Rules only raise verbosity: a matching call site logs events at the rule
+// level or above even if the logger level or the global level would filter
+// them out, unless the logger is Disabled. The call site of each program
+// counter is resolved once and cached, and the check only runs for events
+// that would otherwise be dropped at the level of a rule or above, as finding
+// the call site costs a stack walk. An empty spec removes all rules.
+func SetVmodule(spec string) error {
+	rules, err := parseVmodule(spec, false)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		vmodule.Store(nil)
+		updateLevelOverrides()
+		return nil
+	}
+	lowest := Disabled
+	for _, rule := range rules {
+		lowest = min(lowest, levelFromSlog(rule.level))
+	}
+	vmodule.Store(&vmoduleRules{
+		spec:  spec,
+		rules: rules,
+		gen:   vmoduleGen.Add(1),
+		min:   lowest,
+	})
+	updateLevelOverrides()
+	return nil
+}
+
+// Vmodule returns the spec last passed to SetVmodule.
+func Vmodule() string {
+	if rules := vmodule.Load(); rules != nil {
+		return rules.spec
+	}
+	return ""
+}
+
+const vmoduleMatched = 1 << 8
+
+// vmoduleLevel returns the level the rules assign to the call site.
+func (s *callSite) vmoduleLevel(rules *vmoduleRules) (Level, bool) {
+	c := s.vmodule.Load()
+	if c>>16 != rules.gen {
+		slvl, ok := matchVmodule(rules.rules, s.file, s.function)
+		lvl := levelFromSlog(slvl)
+		c = rules.gen<<16 | uint64(uint8(lvl))
+		if ok {
+			c |= vmoduleMatched
+		}
+		s.vmodule.Store(c)
+	}
+	return Level(int8(uint8(c))), c&vmoduleMatched != 0
+}
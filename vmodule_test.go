@@ -0,0 +1,193 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSetVmodule(t *testing.T) {
+	defer SetVmodule("")
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel)
+
+	l.Debug("before")
+	if err := SetVmodule("vmodule_test.go=debug"); err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("file")
+	l.Trace("trace")
+	if err := SetVmodule("log.TestSetVmodule=trace"); err != nil {
+		t.Fatal(err)
+	}
+	l.TraceEvent().Msg("function")
+	if err := SetVmodule("other.go=trace"); err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("other")
+
+	want := `{"level":"debug","message":"file"}` + "\n" +
+		`{"level":"trace","message":"function"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := SetVmodule("foo.go"); err == nil {
+		t.Error("expected an error for a rule without a level")
+	}
+}
+
+func TestVmodulePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"processor.go", "/src/chain/processor.go", true},
+		{"processor.go", "processor.go", true},
+		{"processor.go", "/src/chain/myprocessor.go", false},
+		{"chain/processor.go", "/src/chain/processor.go", true},
+		{"luxfi/node/p2p", "/go/luxfi/node/p2p/peer.go", true},
+		{"luxfi/node/p2p", "github.com/luxfi/node/p2p.(*Peer).Send", true},
+		{"luxfi/node/p2p", "github.com/luxfi/node/p2pool.Run", false},
+		{"p2p.(*Peer).Send", "github.com/luxfi/node/p2p.(*Peer).Send.func1", true},
+		{"p2p/*", "/go/luxfi/node/p2p/peer.go", true},
+		{"*.go", "/go/luxfi/node/p2p/peer.go", true},
+	}
+	for _, tt := range tests {
+		re, err := compileVmodulePattern(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := re.MatchString(tt.s); got != tt.want {
+			t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestGlogHandlerVmodule(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewGlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: levelMaxVerbosity,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	slog.New(h).Debug("before")
+	if err := h.Vmodule("foo.go,luxfi/log.TestGlogHandlerVmodule=debug,bar.go=x"); err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(h.WithAttrs([]slog.Attr{slog.Int("n", 1)}))
+	l.Debug("package")
+	if err := h.BacktraceAt("vmodule_test.go:1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Vmodule(""); err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("child keeps its rules")
+	slog.New(h).Debug("parent rules removed")
+	l.Info("no backtrace")
+
+	want := "level=DEBUG msg=package n=1\n" +
+		"level=DEBUG msg=\"child keeps its rules\" n=1\n" +
+		"level=INFO msg=\"no backtrace\" n=1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := h.BacktraceAt("vmodule_test.go"); err == nil {
+		t.Error("expected an error for a location without a line")
+	}
+}
+
+func TestGlogHandlerBacktraceAt(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewGlogHandler(slog.NewJSONHandler(&buf, nil))
+	l := slog.New(h)
+
+	_, file, line, _ := runtime.Caller(0)
+	log := func(msg string) { l.Info(msg) }
+	if err := h.BacktraceAt(fmt.Sprintf("%s:%d", filepath.Base(file), line+1)); err != nil {
+		t.Fatal(err)
+	}
+	log("backtrace")
+	l.Info("other line")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	stack, _ := rec[ErrorStackFieldName].(string)
+	if !strings.Contains(stack, "TestGlogHandlerBacktraceAt") {
+		t.Errorf("no stack trace attached to the record: %q", lines[0])
+	}
+	if strings.Contains(lines[1], ErrorStackFieldName) {
+		t.Errorf("stack trace attached to another line: %q", lines[1])
+	}
+}
+
+func TestLevelOverrides(t *testing.T) {
+	defer SetVmodule("")
+	defer SetNamedLevels("")
+	defer SetDynamicDebug(false)
+
+	var buf bytes.Buffer
+	l := NewWriter(&buf).Level(InfoLevel)
+	for _, set := range []struct {
+		name  string
+		set   func(on bool)
+		allow bool
+	}{
+		{"vmodule", func(on bool) {
+			spec := ""
+			if on {
+				spec = "vmodule_test.go=debug"
+			}
+			if err := SetVmodule(spec); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"named", func(on bool) {
+			spec := ""
+			if on {
+				spec = "other=debug"
+			}
+			if err := SetNamedLevels(spec); err != nil {
+				t.Fatal(err)
+			}
+		}, false},
+		{"dynamic debug", SetDynamicDebug, false},
+	} {
+		set.set(true)
+		if !levelOverrides.Load() {
+			t.Errorf("%s: overrides not set", set.name)
+		}
+		buf.Reset()
+		l.Debug("below")
+		if got := buf.Len() > 0; got != set.allow {
+			t.Errorf("%s: logged = %v, want %v", set.name, got, set.allow)
+		}
+		set.set(false)
+		if levelOverrides.Load() {
+			t.Errorf("%s: overrides still set", set.name)
+		}
+	}
+}
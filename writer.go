@@ -36,6 +36,32 @@ func (lw LevelWriterAdapter) Close() error {
 	return nil
 }
 
+// Sync calls the underlying writer's Sync method if it has one. Otherwise
+// does nothing.
+func (lw LevelWriterAdapter) Sync() error {
+	return syncOutput(lw.Writer)
+}
+
+// syncer is implemented by writers that can flush their data to stable
+// storage, such as *os.File and *FileWriter.
+type syncer interface {
+	Sync() error
+}
+
+// syncOutput calls the Sync method of w if it has one.
+func syncOutput(w io.Writer) error {
+	if s, ok := w.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// stdStream wraps os.Stdout or os.Stderr, hiding their Close method so that
+// closing the writers built on top of them leaves the stream open.
+type stdStream struct {
+	io.Writer
+}
+
 type syncWriter struct {
 	mu sync.Mutex
 	lw LevelWriter
@@ -75,6 +101,12 @@ func (s *syncWriter) Close() error {
 	return nil
 }
 
+func (s *syncWriter) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return syncOutput(s.lw)
+}
+
 type multiLevelWriter struct {
 	writers []LevelWriter
 }
@@ -121,6 +153,18 @@ func (t multiLevelWriter) Close() error {
 	return nil
 }
 
+// Sync calls Sync on all the underlying writers that have a Sync method and
+// returns the first error.
+func (t multiLevelWriter) Sync() error {
+	var err error
+	for _, w := range t.writers {
+		if syncErr := syncOutput(w); err == nil {
+			err = syncErr
+		}
+	}
+	return err
+}
+
 // MultiLevelWriter creates a writer that duplicates its writes to all the
 // provided writers, similar to the Unix tee(1) command. If some writers
 // implement LevelWriter, their WriteLevel method will be used instead of Write.
@@ -233,7 +277,7 @@ var triggerWriterPool = &sync.Pool{
 // higher than ConditionalLevel are always written out to the destination
 // writer. If trigger never happens, buffered log lines are never written out.
 //
-// It can be used to configure "log level per request".
+// It can be used to configure "log level per request", see TriggerScope.
 type TriggerLevelWriter struct {
 	// Destination writer. If LevelWriter is provided (usually), its WriteLevel is used
 	// instead of Write.
@@ -247,6 +291,11 @@ type TriggerLevelWriter struct {
 	// level lines. Usually this is set to ErrorLevel.
 	TriggerLevel Level
 
+	// MaxBufferSize is the number of bytes above which the oldest buffered
+	// lines are dropped, to bound the memory held by a writer that is never
+	// triggered. There is no limit if 0.
+	MaxBufferSize int
+
 	buf       *bytes.Buffer
 	triggered bool
 	mu        sync.Mutex
@@ -271,6 +320,20 @@ func (w *TriggerLevelWriter) WriteLevel(l Level, p []byte) (n int, err error) {
 			w.buf = triggerWriterPool.Get().(*bytes.Buffer)
 		}
 
+		if w.MaxBufferSize > 0 {
+			if 1+len(p) > w.MaxBufferSize {
+				return len(p), nil
+			}
+			for w.buf.Len()+1+len(p) > w.MaxBufferSize {
+				i := bytes.IndexByte(w.buf.Bytes(), '\n')
+				if i < 0 {
+					w.buf.Reset()
+					break
+				}
+				w.buf.Next(i + 1)
+			}
+		}
+
 		// We prefix each log line with a byte with the level.
 		// Hopefully we will never have a level value which equals a newline
 		// (which could interfere with reconstruction of log lines in the trigger method).
@@ -333,6 +396,18 @@ func (w *TriggerLevelWriter) Trigger() error {
 	return w.trigger()
 }
 
+// Reset discards the buffered lines and returns the writer to the untriggered
+// state, so that it can be used for another request.
+func (w *TriggerLevelWriter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.triggered = false
+	if w.buf != nil {
+		w.buf.Reset()
+	}
+}
+
 // Close closes the writer and returns the buffer to the pool.
 func (w *TriggerLevelWriter) Close() error {
 	w.mu.Lock()